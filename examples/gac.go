@@ -22,11 +22,10 @@ const (
 )
 
 type umisSensitiveFilter struct {
-        Automation     *Automation
+        Automation     *AutomationOf[[]int] // value 为包含该词的行号
         FullMatchWords map[string]int
         TokensOfLine   []uint8
         Tokens         [][]string
-        LineWordMap    map[string]map[int]interface{}
         WordMap        map[string]*wordObj
 }
 
@@ -62,12 +61,14 @@ func (u *umisSensitiveFilter) IsUmisWords(seq []rune) ([]hitInfo, bool, bool) {
 func parseUmisConfig(remoteResponse []byte) *umisSensitiveFilter {
         lines := strings.Split(string(remoteResponse), umisLineSeparator)
         filter := new(umisSensitiveFilter)
-        filter.Automation = GenAutomation()
+        filter.Automation = GenAutomationOf[[]int]()
         filter.TokensOfLine = make([]uint8, len(lines))
         filter.Tokens = make([][]string, len(lines))
-        filter.LineWordMap = make(map[string]map[int]interface{})
         filter.WordMap = map[string]*wordObj{}
         filter.FullMatchWords = map[string]int{}
+        // 同一个词可能出现在多行，先收集行号再插入
+        var words []string
+        wordLines := map[string][]int{}
         for lineIndex, line := range lines {
                 // 敏感词词表中一行表示一个敏感词或一组敏感词，多个敏感词使用“|”分割
                 // 第一列为敏感词，第二列为审核策略，第三列为匹配策略，列之间使用\t分割
@@ -94,15 +95,20 @@ func parseUmisConfig(remoteResponse []byte) *umisSensitiveFilter {
                                         continue
                                 }
                                 count++
-                                if _, ok := filter.LineWordMap[word]; !ok {
-                                        filter.LineWordMap[word] = make(map[int]interface{})
+                                indexes, ok := wordLines[word]
+                                if !ok {
+                                        words = append(words, word)
+                                }
+                                if len(indexes) == 0 || indexes[len(indexes)-1] != lineIndex {
+                                        wordLines[word] = append(indexes, lineIndex)
                                 }
-                                filter.LineWordMap[word][lineIndex] = nil
-                                filter.Automation.Insert([]rune(word), nil)
                         }
                         filter.TokensOfLine[lineIndex] = count
                 }
         }
+        for _, word := range words {
+                filter.Automation.MustInsert([]rune(word), wordLines[word])
+        }
         filter.Automation.Compile()
         return filter
 }
@@ -120,7 +126,7 @@ func hitUmisWords(filter *umisSensitiveFilter, seq []rune) ([]hitInfo, bool) {
                 return hitList, false
         }
         matcher := filter.Automation
-        matchRes := matcher.Match(seq)
+        matchRes := matcher.MustMatch(seq)
         defer filter.Automation.PoolPut(matchRes)
         if matchRes.Len == 0 {
                 return nil, false
@@ -131,29 +137,27 @@ func hitUmisWords(filter *umisSensitiveFilter, seq []rune) ([]hitInfo, bool) {
         found := false
         var hitStrings []string
         for item := 0; item < matchRes.Len; item++ {
-                keyBytes, _ := filter.Automation.GetMatched(matchRes.Indexes[item])
+                keyBytes, lineIndexes := filter.Automation.MustGetMatched(matchRes.Indexes[item])
                 key := string(keyBytes)
                 // 如果已经处理过了，不用处理了
                 if _, ok := keys[key]; ok {
                         continue
                 }
                 keys[key] = true
-                if values, ok := filter.LineWordMap[key]; ok && values != nil {
-                        for k := range values {
-                                // 下面的循环用来做多词匹配的
-                                // 比如词条“八婆|死肥猪”
-                                // 那么待审核的文本中必须同时包含“八婆”和“死肥猪”才算命中
-                                lineInfo[k]++
-                                if filter.TokensOfLine[k] <= lineInfo[k] {
-                                        hitStrings = filter.Tokens[k]
-                                        if word, ok := filter.WordMap[strings.Join(hitStrings, "|")]; ok {
-                                                h := hitInfo{
-                                                        HitWord: key,
-                                                        KeyInfo: *word,
-                                                }
-                                                hitList = append(hitList, h)
-                                                found = true
+                for _, k := range lineIndexes {
+                        // 下面的循环用来做多词匹配的
+                        // 比如词条“八婆|死肥猪”
+                        // 那么待审核的文本中必须同时包含“八婆”和“死肥猪”才算命中
+                        lineInfo[k]++
+                        if filter.TokensOfLine[k] <= lineInfo[k] {
+                                hitStrings = filter.Tokens[k]
+                                if word, ok := filter.WordMap[strings.Join(hitStrings, "|")]; ok {
+                                        h := hitInfo{
+                                                HitWord: key,
+                                                KeyInfo: *word,
                                         }
+                                        hitList = append(hitList, h)
+                                        found = true
                                 }
                         }
                 }
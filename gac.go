@@ -1,107 +1,164 @@
 package tools
 
 import (
+        "errors"
+        "sort"
         "sync"
 )
 
 const (
-        indexesLen = 256
+        indexesLen    = 256     // IndexesInfo 初始容量
+        indexesMaxLen = 1 << 16 // 容量超过该值的 IndexesInfo 不放回 pool
+)
+
+var (
+        ErrCompiled    = errors.New("automation: compiled already") // Compile 之后不能再插入模式串
+        ErrNotCompiled = errors.New("automation: not compiled")     // Compile 之前不能匹配
+        ErrBadIndex    = errors.New("automation: index is illegal") // 模式串下标越界
+        ErrInserted    = errors.New("automation: inserted already") // Insert 之后不能再修改归一化和噪声字符
+)
+
+// Backend 编译后状态转移表的存储方式
+type Backend int
+
+const (
+        BackendMap         Backend = iota // 每个状态一个 map，默认
+        BackendDoubleArray                // 双数组，内存占用小，转移不需要 map 查找
 )
 
 type node struct {
-        parent   *node
         children map[rune]*node
-        fail     *node
         value    rune
         index    int
 }
 
+// IndexesInfo 一次匹配的全部命中，第 i 个命中为 Indexes[i]、StartPoses[i]、EndPoses[i]
+// 对于 UTF-8 文本，s[ByteStartPoses[i]:ByteEndPoses[i]] 即命中的内容
 type IndexesInfo struct {
-        Indexes  [indexesLen]int
-        Len      int
-        EndPoses [indexesLen]int
-}
-
-func (nd *node) isRoot() bool {
-        return nd.parent == nil
-}
-
-type element struct {
-        nd   *node
-        next *element
-}
-
-type list struct {
-        head *element
-        tail *element
+        Indexes        []int
+        Len            int
+        EndPoses       []int
+        StartPoses     []int
+        ByteStartPoses []int
+        ByteEndPoses   []int
+        Truncated      bool // 命中数超过 MatchLimit 的 limit，结果被截断
 }
 
-func (l *list) popHead() *element {
-        if l.head != nil {
-                if l.head == l.tail {
-                        l.tail = nil
-                }
-                tmp := l.head
-                l.head = tmp.next
-                return tmp
+func newIndexesInfo() interface{} {
+        return &IndexesInfo{
+                Indexes:        make([]int, 0, indexesLen),
+                EndPoses:       make([]int, 0, indexesLen),
+                StartPoses:     make([]int, 0, indexesLen),
+                ByteStartPoses: make([]int, 0, indexesLen),
+                ByteEndPoses:   make([]int, 0, indexesLen),
         }
-        return nil
 }
 
-func (l *list) push(e *element) {
-        if e == nil {
-                return
-        }
-        e.next = nil
-        if l.head == nil {
-                l.head = e
-                l.tail = l.head
-        } else {
-                l.tail.next = e
-                l.tail = e
-        }
+func addHit[V any](indexes *IndexesInfo, h Hit[V]) {
+        indexes.Indexes = append(indexes.Indexes, h.Index)
+        indexes.EndPoses = append(indexes.EndPoses, h.End-1)
+        indexes.StartPoses = append(indexes.StartPoses, h.Start)
+        indexes.ByteStartPoses = append(indexes.ByteStartPoses, h.ByteStart)
+        indexes.ByteEndPoses = append(indexes.ByteEndPoses, h.ByteEnd)
+        indexes.Len++
 }
 
-type Automation struct {
+// AutomationOf value 类型为 V 的AC自动机
+// Compile 之后每个状态用 int32 编号，按 BFS 顺序编号，根节点为 0，
+// 同一状态的子状态编号连续且按字符排序，子状态区间为 [firsts[s], firsts[s+1])
+type AutomationOf[V any] struct {
         root     node
         compiled bool
         pool     *sync.Pool
         datas    [][]rune
-        values   []interface{}
+        values   []V
         dataLen  int
+        codec    ValueCodecOf[V]
+        err      error
+        backend  Backend
+        labels   []rune  // 进入状态的字符
+        fails    []int32 // fail 指针
+        dicts    []int32 // 输出链接，沿 fail 链最近的模式串结尾状态，0 表示没有
+        roots    []int32 // 根状态的稠密转移表，下标为 BMP 字符，没有子状态时为 0
+        outputs  []int32 // 状态对应的模式串下标，-1 表示不是模式串结尾
+        depths   []int32 // 状态的深度
+        firsts   []int32
+        children []map[rune]int32 // BackendMap 的转移表
+        dat      *doubleArray     // BackendDoubleArray 的转移表
+        kind     MatchKind
+        maxDepth int // 最长模式串的长度
+        norms    []Normalizer
+        alts     Confusables
+        skip     SkipSet // 匹配时跳过的噪声字符
+        maxGap   int     // 相邻两个字符之间最多跳过的字符数，<= 0 表示不限制
+        word     bool    // 之后 Insert 的模式串是否要求单词边界
+        bounds   []uint8 // 模式串两端的单词边界要求
+        bounded  bool    // 是否有模式串要求单词边界
+        workers  int     // MatchBatch 的 goroutine 数
+        dup      DuplicatePolicy
+        multi    map[int][]V // DuplicateAccumulate 时有多个 value 的模式串的全部 value
 }
 
+// Automation value 为 interface{} 的AC自动机
+type Automation = AutomationOf[interface{}]
+
 func GenAutomation() *Automation {
-        ac := &Automation{
+        return GenAutomationOf[interface{}]()
+}
+
+// GenAutomationOf 创建 value 类型为 V 的AC自动机
+func GenAutomationOf[V any]() *AutomationOf[V] {
+        ac := &AutomationOf[V]{
                 compiled: false,
                 root: node{
                         children: map[rune]*node{},
                         index:    -1,
                 },
                 pool: &sync.Pool{
-                        New: func() interface{} {
-                                return &IndexesInfo{}
-                        },
+                        New: newIndexesInfo,
                 },
                 datas: make([][]rune, 0),
         }
         return ac
 }
 
-func (ac *Automation) Insert(data []rune, value interface{}) {
+// SetBackend 设置 Compile 时使用的状态转移表存储方式
+func (ac *AutomationOf[V]) SetBackend(backend Backend) *AutomationOf[V] {
+        ac.backend = backend
+        return ac
+}
+
+// Insert 插入模式串，设置了 Normalizer 时 trie 中保存归一化之后的模式串，
+// GetMatched 仍然返回插入时的模式串
+// 已经 Compile 时返回 ErrCompiled，Insert 之后调用过 SetNormalizer 或 SetSkip 时返回 ErrInserted
+func (ac *AutomationOf[V]) Insert(data []rune, value V) error {
+        _, err := ac.insert(data, value, ac.word)
+        return err
+}
+
+// MustInsert 同 Insert，出错时 panic
+func (ac *AutomationOf[V]) MustInsert(data []rune, value V) {
+        must(ac.Insert(data, value))
+}
+
+// insert 返回模式串的下标，归一化之后为空的模式串不会被插入，返回 -1
+func (ac *AutomationOf[V]) insert(data []rune, value V, word bool) (int, error) {
         if ac.compiled {
-                panic("compiled already")
+                return -1, ErrCompiled
         }
-        if len(data) == 0 {
-                return
+        if ac.err != nil {
+                return -1, ac.err
+        }
+        key := ac.normalizeKey(data)
+        if len(key) == 0 {
+                return -1, nil
         }
         currentNode := &ac.root
-        for _, d := range data {
+        for _, d := range key {
                 if child, exist := currentNode.children[d]; exist {
                         currentNode = child
                 } else {
                         tmpNode := &node{
-                                parent:   currentNode,
                                 children: map[rune]*node{},
                                 value:    d,
                                 index:    -1,
@@ -110,101 +167,263 @@ func (ac *Automation) Insert(data []rune, value interface{}) {
                         currentNode = tmpNode
                 }
         }
+        if currentNode.index >= 0 {
+                ac.duplicate(currentNode.index, data, value, key, word)
+                return currentNode.index, nil
+        }
         ac.datas = append(ac.datas, data)
         ac.values = append(ac.values, value)
+        ac.bounds = append(ac.bounds, wordBounds(key, word))
+        ac.bounded = ac.bounded || ac.bounds[len(ac.bounds)-1] != 0
         currentNode.index = len(ac.datas) - 1
+        return currentNode.index, nil
 }
 
-func (ac *Automation) Compile() {
+// Compile 把 trie 压平为状态数组并构建 fail 指针，之后不能再 Insert
+// Insert 之后调用过 SetNormalizer 或 SetSkip 时不编译，返回 ErrInserted
+func (ac *AutomationOf[V]) Compile() error {
+        if ac.err != nil {
+                return ac.err
+        }
+        if ac.compiled {
+                return nil
+        }
         ac.compiled = true
         ac.dataLen = len(ac.datas)
+        ac.flatten()
+        ac.buildGoto()
         ac.buildFails()
+        ac.buildDicts()
+        // 压平之后不再需要指针形式的 trie
+        ac.root = node{index: -1}
+        return nil
+}
+
+// MustCompile 同 Compile，出错时 panic
+func (ac *AutomationOf[V]) MustCompile() {
+        must(ac.Compile())
 }
 
-func (ac *Automation) findFail(cn *node) *node {
-        fail := cn.parent.fail
-        for {
-                if n, exist := fail.children[cn.value]; exist {
-                        return n
-                } else if fail.isRoot() {
-                        return fail
+// flatten 按 BFS 顺序给节点编号，同一节点的子节点按字符排序
+func (ac *AutomationOf[V]) flatten() {
+        nodes := []*node{&ac.root}
+        ac.firsts = []int32{}
+        for i := 0; i < len(nodes); i++ {
+                start := len(nodes)
+                ac.firsts = append(ac.firsts, int32(start))
+                for _, child := range nodes[i].children {
+                        nodes = append(nodes, child)
+                }
+                children := nodes[start:]
+                sort.Slice(children, func(a, b int) bool {
+                        return children[a].value < children[b].value
+                })
+        }
+        ac.firsts = append(ac.firsts, int32(len(nodes)))
+
+        ac.labels = make([]rune, len(nodes))
+        ac.outputs = make([]int32, len(nodes))
+        ac.depths = make([]int32, len(nodes))
+        ac.fails = make([]int32, len(nodes))
+        ac.maxDepth = 0
+        for s, nd := range nodes {
+                ac.labels[s] = nd.value
+                ac.outputs[s] = int32(nd.index)
+                for t := ac.firsts[s]; t < ac.firsts[s+1]; t++ {
+                        ac.depths[t] = ac.depths[s] + 1
+                }
+                if int(ac.depths[s]) > ac.maxDepth {
+                        ac.maxDepth = int(ac.depths[s])
+                }
+        }
+}
+
+func (ac *AutomationOf[V]) buildGoto() {
+        ac.buildRoots()
+        ac.children, ac.dat = nil, nil
+        if ac.backend == BackendDoubleArray {
+                ac.dat = buildDoubleArray(ac.labels, ac.firsts)
+                return
+        }
+        ac.children = make([]map[rune]int32, len(ac.labels))
+        for s := range ac.children {
+                lo, hi := ac.firsts[s], ac.firsts[s+1]
+                if lo == hi {
+                        continue
+                }
+                ac.children[s] = make(map[rune]int32, hi-lo)
+                for t := lo; t < hi; t++ {
+                        ac.children[s][ac.labels[t]] = t
                 }
-                fail = fail.fail
         }
 }
 
-func (ac *Automation) buildFails() {
-        el := list{}
-        el.push(&element{nd: &ac.root})
-        ce := el.popHead()
-        for ce != nil {
-                for _, n := range ce.nd.children {
-                        el.push(&element{nd: n})
+// buildRoots 构建根状态的稠密转移表，匹配时大部分字符都从根状态转移
+// 表长为根状态最大的 BMP 子字符加一，BMP 之外的子字符仍然查转移表
+func (ac *AutomationOf[V]) buildRoots() {
+        size := 0
+        for t := ac.firsts[0]; t < ac.firsts[1]; t++ {
+                if r := ac.labels[t]; r >= 0 && r < bmpLen {
+                        size = int(r) + 1
                 }
-                if !ce.nd.isRoot() {
-                        if ce.nd.parent == &ac.root {
-                                ce.nd.fail = &ac.root
+        }
+        ac.roots = make([]int32, size)
+        for t := ac.firsts[0]; t < ac.firsts[1]; t++ {
+                if r := ac.labels[t]; r >= 0 && r < bmpLen {
+                        ac.roots[r] = t
+                }
+        }
+}
+
+// buildFails 按 BFS 顺序构建 fail 指针，父状态的 fail 总是先于子状态算出
+func (ac *AutomationOf[V]) buildFails() {
+        for s := int32(0); int(s) < len(ac.labels); s++ {
+                for t := ac.firsts[s]; t < ac.firsts[s+1]; t++ {
+                        if s == 0 {
+                                ac.fails[t] = 0
                         } else {
-                                ce.nd.fail = ac.findFail(ce.nd)
+                                ac.fails[t] = ac.step(ac.fails[s], ac.labels[t])
                         }
                 }
-                ce = el.popHead()
         }
 }
 
-func (ac *Automation) getData(nd *node, indexes *IndexesInfo, endPos int) {
-        fail := nd.fail
-        for fail != nil {
-                if fail.index != -1 {
-                        if indexes.Len == indexesLen {
-                                return
-                        }
-                        indexes.Indexes[indexes.Len] = fail.index
-                        indexes.EndPoses[indexes.Len] = endPos
-                        indexes.Len++
+// buildDicts 按 BFS 顺序构建输出链接，匹配时只需要访问真正的模式串结尾，
+// 每个字符的开销与 fail 链的长度无关
+func (ac *AutomationOf[V]) buildDicts() {
+        ac.dicts = make([]int32, len(ac.labels))
+        for t := 1; t < len(ac.labels); t++ {
+                f := ac.fails[t]
+                if ac.outputs[f] >= 0 {
+                        ac.dicts[t] = f
+                } else {
+                        ac.dicts[t] = ac.dicts[f]
+                }
+        }
+}
+
+// next 状态 s 读入字符 r 的 goto 转移，不存在时返回 -1
+func (ac *AutomationOf[V]) next(s int32, r rune) int32 {
+        if ac.dat != nil {
+                return ac.dat.next(s, r)
+        }
+        if t, exist := ac.children[s][r]; exist {
+                return t
+        }
+        return -1
+}
+
+// step 状态 s 读入字符 r 之后的状态，goto 失败时沿 fail 指针回退
+func (ac *AutomationOf[V]) step(s int32, r rune) int32 {
+        for s != 0 {
+                if t := ac.next(s, r); t >= 0 {
+                        return t
                 }
-                fail = fail.fail
+                s = ac.fails[s]
+        }
+        if uint32(r) < uint32(len(ac.roots)) {
+                return ac.roots[r]
         }
+        if r >= 0 && r < bmpLen {
+                return 0
+        }
+        if t := ac.next(0, r); t >= 0 {
+                return t
+        }
+        return 0
+}
+
+// Match 按照 SetMatchKind 设置的方式匹配，返回全部命中，没有 Compile 时返回 ErrNotCompiled
+// EndPoses 为命中的最后一个字符的位置，StartPoses 为第一个字符的位置
+func (ac *AutomationOf[V]) Match(seq []rune) (*IndexesInfo, error) {
+        return ac.MatchLimit(seq, 0)
+}
+
+// MustMatch 同 Match，出错时 panic
+func (ac *AutomationOf[V]) MustMatch(seq []rune) *IndexesInfo {
+        return mustIndexes(ac.Match(seq))
 }
 
-func (ac *Automation) Match(seq []rune) *IndexesInfo {
+// MatchLimit 同 Match，最多返回 limit 个命中，limit <= 0 时不限制
+// 还有更多命中时 Truncated 为 true
+func (ac *AutomationOf[V]) MatchLimit(seq []rune, limit int) (*IndexesInfo, error) {
+        return ac.collect(runesInput[V](seq), limit)
+}
+
+// MustMatchLimit 同 MatchLimit，出错时 panic
+func (ac *AutomationOf[V]) MustMatchLimit(seq []rune, limit int) *IndexesInfo {
+        return mustIndexes(ac.MatchLimit(seq, limit))
+}
+
+// MatchFunc 按照 SetMatchKind 设置的方式匹配，每次命中调用 fn，fn 返回 false 时停止
+// 命中数量没有限制，也不需要 PoolPut
+func (ac *AutomationOf[V]) MatchFunc(seq []rune, fn func(Hit[V]) bool) error {
+        if !ac.compiled {
+                return ErrNotCompiled
+        }
+        ac.matchFunc(runesInput[V](seq), fn)
+        return nil
+}
+
+// MustMatchFunc 同 MatchFunc，出错时 panic
+func (ac *AutomationOf[V]) MustMatchFunc(seq []rune, fn func(Hit[V]) bool) {
+        must(ac.MatchFunc(seq, fn))
+}
+
+func (ac *AutomationOf[V]) collect(in input[V], limit int) (*IndexesInfo, error) {
         if !ac.compiled {
-                panic("not compiled")
+                return nil, ErrNotCompiled
         }
         indexes := ac.pool.Get().(*IndexesInfo)
-        currentNode := &ac.root
-        for ind, s := range seq {
-                for {
-                        if n, exist := currentNode.children[s]; exist {
-                                if indexes.Len == indexesLen {
-                                        return indexes
-                                }
-                                currentNode = n
-                                if currentNode.index != -1 {
-                                        indexes.Indexes[indexes.Len] = currentNode.index
-                                        indexes.EndPoses[indexes.Len] = ind
-                                        indexes.Len++
-                                }
-                                ac.getData(currentNode, indexes, ind)
-                                break
-                        } else if currentNode.isRoot() {
-                                break
-                        } else {
-                                currentNode = currentNode.fail
-                        }
+        ac.matchFunc(in, func(h Hit[V]) bool {
+                if limit > 0 && indexes.Len == limit {
+                        indexes.Truncated = true
+                        return false
                 }
-        }
-        return indexes
+                addHit(indexes, h)
+                return true
+        })
+        return indexes, nil
 }
 
-func (ac *Automation) GetMatched(index int) ([]rune, interface{}) {
+// GetMatched 下标为 index 的模式串及其 value，下标越界时返回 ErrBadIndex
+// DuplicateAccumulate 时 value 为第一个插入的 value，全部 value 通过 GetMatchedAll 获取
+func (ac *AutomationOf[V]) GetMatched(index int) ([]rune, V, error) {
         if index < 0 || index >= ac.dataLen {
-                panic("index is illegal")
+                var zero V
+                return nil, zero, ErrBadIndex
         }
-        return ac.datas[index], ac.values[index]
+        return ac.datas[index], ac.values[index], nil
+}
+
+// MustGetMatched 同 GetMatched，出错时 panic
+func (ac *AutomationOf[V]) MustGetMatched(index int) ([]rune, V) {
+        data, value, err := ac.GetMatched(index)
+        must(err)
+        return data, value
 }
 
-func (ac *Automation) PoolPut(indexes *IndexesInfo) {
+func (ac *AutomationOf[V]) PoolPut(indexes *IndexesInfo) {
+        if cap(indexes.Indexes) > indexesMaxLen {
+                return
+        }
+        indexes.Indexes = indexes.Indexes[:0]
+        indexes.EndPoses = indexes.EndPoses[:0]
+        indexes.StartPoses = indexes.StartPoses[:0]
+        indexes.ByteStartPoses = indexes.ByteStartPoses[:0]
+        indexes.ByteEndPoses = indexes.ByteEndPoses[:0]
         indexes.Len = 0
+        indexes.Truncated = false
         ac.pool.Put(indexes)
 }
+
+func must(err error) {
+        if err != nil {
+                panic(err)
+        }
+}
+
+func mustIndexes(indexes *IndexesInfo, err error) *IndexesInfo {
+        must(err)
+        return indexes
+}
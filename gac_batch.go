@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// SetBatchWorkers 设置 MatchBatch 最多使用的 goroutine 数，<= 0 时使用 GOMAXPROCS
+func (ac *AutomationOf[V]) SetBatchWorkers(n int) *AutomationOf[V] {
+	ac.workers = n
+	return ac
+}
+
+// MatchBatch 并发匹配多段 UTF-8 文本，第 i 个结果为 texts[i] 的全部命中，匹配方式同 MatchString
+// ctx 取消时尽快停止，返回 ctx.Err()；没有 Compile 时返回 ErrNotCompiled
+func (ac *AutomationOf[V]) MatchBatch(ctx context.Context, texts []string) ([][]Hit[V], error) {
+	if !ac.compiled {
+		return nil, ErrNotCompiled
+	}
+	workers := ac.workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(texts) {
+		workers = len(texts)
+	}
+	results := make([][]Hit[V], len(texts))
+	var next int64 = -1
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= len(texts) {
+					return
+				}
+				results[i] = ac.batchHits(texts[i])
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// batchHits 使用 pool 中的 IndexesInfo 匹配，结果复制为 []Hit 之后放回 pool
+func (ac *AutomationOf[V]) batchHits(s string) []Hit[V] {
+	indexes, _ := ac.collect(stringInput[V](s), 0)
+	defer ac.PoolPut(indexes)
+	if indexes.Len == 0 {
+		return nil
+	}
+	hits := make([]Hit[V], indexes.Len)
+	for i := range hits {
+		index := indexes.Indexes[i]
+		hits[i] = Hit[V]{
+			Index:     index,
+			Start:     indexes.StartPoses[i],
+			End:       indexes.EndPoses[i] + 1,
+			ByteStart: indexes.ByteStartPoses[i],
+			ByteEnd:   indexes.ByteEndPoses[i],
+			Value:     ac.values[index],
+			Values:    ac.valuesOf(index),
+		}
+	}
+	return hits
+}
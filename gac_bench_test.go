@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// 基准测试对比不同 Backend 的构建开销、构建后占用的内存和各种匹配方式的吞吐，例如
+// go test -run '^$' -bench . -benchmem
+//
+// 数据集:
+//   han  常用汉字组成的 2-6 字词，模拟中文敏感词，文本随机生成，几乎没有命中
+//   hits 与 han 相同的词，文本中每隔几个字出现一个词典中的词
+//   deep 4 个字母组成的 16-32 字母的长串，trie 很深、fail 链很长，但命中很少
+
+const (
+	benchWords = 100000 // 模式串数量
+	benchText  = 4096   // 待匹配文本的字符数
+)
+
+type benchDataset struct {
+	name  string
+	words [][]rune
+	text  []rune
+}
+
+var (
+	benchOnce     sync.Once
+	benchDatasets []benchDataset
+	benchCache    = map[string]*Automation{}
+)
+
+var benchBackends = []struct {
+	name    string
+	backend Backend
+}{
+	{"map", BackendMap},
+	{"double-array", BackendDoubleArray},
+}
+
+func benchWord(rnd *rand.Rand, first rune, size, minLen, maxLen int) []rune {
+	w := make([]rune, minLen+rnd.Intn(maxLen-minLen+1))
+	for i := range w {
+		w[i] = first + rune(rnd.Intn(size))
+	}
+	return w
+}
+
+func genBenchDataset(name string, first rune, size, minLen, maxLen int) benchDataset {
+	rnd := rand.New(rand.NewSource(1))
+	ds := benchDataset{name: name, words: make([][]rune, benchWords)}
+	for i := range ds.words {
+		ds.words[i] = benchWord(rnd, first, size, minLen, maxLen)
+	}
+	ds.text = benchWord(rnd, first, size, benchText, benchText)
+	return ds
+}
+
+// plantWords 随机文本中每隔 1-8 个字插入一个 words 中的词
+func plantWords(ds benchDataset, name string) benchDataset {
+	rnd := rand.New(rand.NewSource(2))
+	text := make([]rune, 0, benchText+16)
+	for len(text) < benchText {
+		i := rnd.Intn(len(ds.text) - 8)
+		text = append(text, ds.text[i:i+1+rnd.Intn(8)]...)
+		text = append(text, ds.words[rnd.Intn(len(ds.words))]...)
+	}
+	return benchDataset{name: name, words: ds.words, text: text[:benchText]}
+}
+
+func benchData() []benchDataset {
+	benchOnce.Do(func() {
+		han := genBenchDataset("han", 0x4E00, 3000, 2, 6)
+		benchDatasets = []benchDataset{
+			han,
+			plantWords(han, "hits"),
+			genBenchDataset("deep", 'a', 4, 16, 32),
+		}
+	})
+	return benchDatasets
+}
+
+func buildBench(words [][]rune, backend Backend) *Automation {
+	ac := GenAutomation().SetBackend(backend)
+	for i, w := range words {
+		ac.MustInsert(w, i)
+	}
+	ac.MustCompile()
+	return ac
+}
+
+// benchEach 对每个数据集和 Backend 运行 fn，自动机只构建一次
+func benchEach(b *testing.B, fn func(b *testing.B, ac *Automation, ds benchDataset)) {
+	for _, ds := range benchData() {
+		for _, be := range benchBackends {
+			ds, be := ds, be
+			b.Run(ds.name+"/"+be.name, func(b *testing.B) {
+				key := ds.name + "/" + be.name
+				ac := benchCache[key]
+				if ac == nil {
+					ac = buildBench(ds.words, be.backend)
+					benchCache[key] = ac
+				}
+				b.SetBytes(int64(len(string(ds.text))))
+				b.ReportAllocs()
+				b.ResetTimer()
+				fn(b, ac, ds)
+			})
+		}
+	}
+}
+
+func BenchmarkBuild(b *testing.B) {
+	for _, ds := range benchData() {
+		for _, be := range benchBackends {
+			ds, be := ds, be
+			b.Run(ds.name+"/"+be.name, func(b *testing.B) {
+				b.ReportAllocs()
+				var ac *Automation
+				for i := 0; i < b.N; i++ {
+					ac = buildBench(ds.words, be.backend)
+				}
+				// 构建完成后自动机保留的内存，B/op 是构建过程中的分配
+				b.ReportMetric(float64(ac.MustStats().Memory), "bytes")
+			})
+		}
+	}
+}
+
+func BenchmarkMatch(b *testing.B) {
+	benchEach(b, func(b *testing.B, ac *Automation, ds benchDataset) {
+		hits := 0
+		for i := 0; i < b.N; i++ {
+			r := ac.MustMatch(ds.text)
+			hits = r.Len
+			ac.PoolPut(r)
+		}
+		b.ReportMetric(float64(hits), "hits/op")
+	})
+}
+
+func BenchmarkMatchFunc(b *testing.B) {
+	benchEach(b, func(b *testing.B, ac *Automation, ds benchDataset) {
+		for i := 0; i < b.N; i++ {
+			ac.MustMatchFunc(ds.text, func(Hit[interface{}]) bool { return true })
+		}
+	})
+}
+
+func BenchmarkMatchString(b *testing.B) {
+	benchEach(b, func(b *testing.B, ac *Automation, ds benchDataset) {
+		s := string(ds.text)
+		for i := 0; i < b.N; i++ {
+			ac.PoolPut(ac.MustMatchString(s))
+		}
+	})
+}
+
+func BenchmarkMatcher(b *testing.B) {
+	benchEach(b, func(b *testing.B, ac *Automation, ds benchDataset) {
+		s := string(ds.text)
+		m := ac.MustNewMatcher(func(Hit[interface{}]) bool { return true })
+		for i := 0; i < b.N; i++ {
+			m.Reset()
+			m.WriteString(s)
+			m.Close()
+		}
+	})
+}
+
+func BenchmarkMatchLeftmostLongest(b *testing.B) {
+	benchEach(b, func(b *testing.B, ac *Automation, ds benchDataset) {
+		ac.SetMatchKind(MatchLeftmostLongest)
+		defer ac.SetMatchKind(MatchStandard)
+		for i := 0; i < b.N; i++ {
+			ac.PoolPut(ac.MustMatch(ds.text))
+		}
+	})
+}
+
+// BenchmarkMatchFuzzy 近似匹配比精确匹配慢得多，只取文本开头 200 个字
+func BenchmarkMatchFuzzy(b *testing.B) {
+	for _, k := range []int{1, 2} {
+		opts := FuzzyOptions{MaxDistance: k}
+		b.Run(fmt.Sprintf("k=%d", k), func(b *testing.B) {
+			benchEach(b, func(b *testing.B, ac *Automation, ds benchDataset) {
+				text := ds.text[:200]
+				b.SetBytes(int64(len(string(text))))
+				hits := 0
+				for i := 0; i < b.N; i++ {
+					hits = len(ac.MustMatchFuzzy(text, opts))
+				}
+				b.ReportMetric(float64(hits), "hits/op")
+			})
+		})
+	}
+}
@@ -0,0 +1,448 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// 序列化格式:
+// magic(4) | version(2) | flags(2) | 模式串及 value | 节点 | crc32(4)
+// 状态按照编号顺序（不含根状态）依次写入 parent、字符、模式串下标、fail
+const (
+	binaryMagic   = "GACB"
+	binaryVersion = 1
+
+	binaryNormalized = 1 << 0 // 模式串经过了 Normalizer 处理
+	binarySkipped    = 1 << 1 // 模式串去掉了 SetSkip 设置的噪声字符
+	binaryBounded    = 1 << 2 // 每个模式串的 value 之后有单词边界要求
+	binaryMulti      = 1 << 3 // 每个模式串最后有额外 value 的个数及各个 value
+
+	binaryConfigFlags = binaryNormalized | binarySkipped // 需要恢复时的设置与序列化时一致
+)
+
+// ValueCodecOf 序列化 AutomationOf[V] 时 value 的编解码器
+type ValueCodecOf[V any] interface {
+	EncodeValue(v V) ([]byte, error)
+	DecodeValue(b []byte) (V, error)
+}
+
+// ValueCodec value 为 interface{} 的编解码器
+type ValueCodec = ValueCodecOf[interface{}]
+
+// GobValueCodec 使用 encoding/gob 编解码 interface{} 类型的 value，
+// 自定义类型需要先 gob.Register；AutomationOf[V] 默认使用 GobCodec[V]，不需要注册
+var GobValueCodec ValueCodec = gobCodec[interface{}]{}
+
+// StringValueCodec 直接保存 string 类型的 value
+var StringValueCodec ValueCodecOf[string] = stringCodec{}
+
+// GobCodec 使用 encoding/gob 直接编解码 V 类型的 value，默认使用
+// 零值（包括 nil 指针）编码为空字节，解码为零值
+func GobCodec[V any]() ValueCodecOf[V] {
+	return gobCodec[V]{}
+}
+
+type gobCodec[V any] struct{}
+
+func (gobCodec[V]) EncodeValue(v V) ([]byte, error) {
+	if reflect.ValueOf(&v).Elem().IsZero() {
+		return nil, nil
+	}
+	buff := &bytes.Buffer{}
+	if err := gob.NewEncoder(buff).Encode(&v); err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
+
+func (gobCodec[V]) DecodeValue(b []byte) (V, error) {
+	var v V
+	if len(b) == 0 {
+		return v, nil
+	}
+	err := gob.NewDecoder(bytes.NewReader(b)).Decode(&v)
+	return v, err
+}
+
+type stringCodec struct{}
+
+func (stringCodec) EncodeValue(v string) ([]byte, error) {
+	return []byte(v), nil
+}
+
+func (stringCodec) DecodeValue(b []byte) (string, error) {
+	return string(b), nil
+}
+
+// SetValueCodec 设置序列化 value 使用的编解码器
+func (ac *AutomationOf[V]) SetValueCodec(codec ValueCodecOf[V]) *AutomationOf[V] {
+	ac.codec = codec
+	return ac
+}
+
+func (ac *AutomationOf[V]) valueCodec() ValueCodecOf[V] {
+	if ac.codec == nil {
+		return GobCodec[V]()
+	}
+	return ac.codec
+}
+
+// MarshalBinary 序列化已编译的自动机，包括 trie、fail 指针、模式串和 value
+func (ac *AutomationOf[V]) MarshalBinary() ([]byte, error) {
+	if !ac.compiled {
+		return nil, ErrNotCompiled
+	}
+	codec := ac.valueCodec()
+	w := &binWriter{}
+	w.buff.WriteString(binaryMagic)
+	w.uint16(binaryVersion)
+	flags := ac.binaryFlags()
+	if ac.bounded {
+		flags |= binaryBounded
+	}
+	if len(ac.multi) > 0 {
+		flags |= binaryMulti
+	}
+	w.uint16(flags)
+
+	w.uvarint(uint64(ac.dataLen))
+	for i := 0; i < ac.dataLen; i++ {
+		w.runes(ac.datas[i])
+		b, err := codec.EncodeValue(ac.values[i])
+		if err != nil {
+			return nil, err
+		}
+		w.bytes(b)
+		if ac.bounded {
+			w.uvarint(uint64(ac.bounds[i]))
+		}
+		if len(ac.multi) > 0 {
+			extra := ac.valuesOf(i)[1:]
+			w.uvarint(uint64(len(extra)))
+			for _, v := range extra {
+				b, err := codec.EncodeValue(v)
+				if err != nil {
+					return nil, err
+				}
+				w.bytes(b)
+			}
+		}
+	}
+
+	w.uvarint(uint64(len(ac.labels)))
+	for parent := int32(0); int(parent) < len(ac.labels); parent++ {
+		for s := ac.firsts[parent]; s < ac.firsts[parent+1]; s++ {
+			w.uvarint(uint64(parent))
+			w.varint(int64(ac.labels[s]))
+			w.varint(int64(ac.outputs[s]))
+			w.uvarint(uint64(ac.fails[s]))
+		}
+	}
+
+	w.uint32(crc32.ChecksumIEEE(w.buff.Bytes()))
+	return w.buff.Bytes(), nil
+}
+
+// UnmarshalBinary 从 MarshalBinary 的结果恢复自动机，不需要再 Compile
+// 状态转移表按照当前 SetBackend 设置的方式构建；Normalizer 和 SetSkip 的设置不会被序列化，
+// 需要在恢复之前设置与序列化时相同的 Normalizer 和噪声字符
+func (ac *AutomationOf[V]) UnmarshalBinary(data []byte) error {
+	if len(data) < len(binaryMagic)+8 {
+		return fmt.Errorf("automation: data too short")
+	}
+	if string(data[:len(binaryMagic)]) != binaryMagic {
+		return fmt.Errorf("automation: bad magic")
+	}
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return fmt.Errorf("automation: checksum mismatch")
+	}
+	r := &binReader{data: body, off: len(binaryMagic)}
+	if version := r.uint16(); version != binaryVersion {
+		return fmt.Errorf("automation: unsupported version %d", version)
+	}
+	flags := r.uint16()
+	if flags&binaryConfigFlags != ac.binaryFlags() {
+		return fmt.Errorf("automation: flags %#x do not match, check SetNormalizer and SetSkip", flags)
+	}
+	if flags&^(binaryConfigFlags|binaryBounded|binaryMulti) != 0 {
+		return fmt.Errorf("automation: unknown flags %#x", flags)
+	}
+
+	codec := ac.valueCodec()
+	dataLen := r.count()
+	datas := make([][]rune, dataLen)
+	values := make([]V, dataLen)
+	bounds := make([]uint8, dataLen)
+	bounded := false
+	var multi map[int][]V
+	for i := 0; i < dataLen && r.err == nil; i++ {
+		datas[i] = r.runes()
+		b := r.bytes()
+		if flags&binaryBounded != 0 {
+			bounds[i] = uint8(r.uvarint())
+			bounded = bounded || bounds[i] != 0
+		}
+		var extras [][]byte
+		if flags&binaryMulti != 0 {
+			extras = make([][]byte, r.count())
+			for j := range extras {
+				extras[j] = r.bytes()
+			}
+		}
+		if r.err != nil {
+			break
+		}
+		value, err := codec.DecodeValue(b)
+		if err != nil {
+			return err
+		}
+		values[i] = value
+		if len(extras) == 0 {
+			continue
+		}
+		if multi == nil {
+			multi = map[int][]V{}
+		}
+		multi[i] = append(multi[i], value)
+		for _, b := range extras {
+			value, err := codec.DecodeValue(b)
+			if err != nil {
+				return err
+			}
+			multi[i] = append(multi[i], value)
+		}
+	}
+
+	// 根状态不写入，其余每个状态至少占一个字节
+	n := r.uvarint()
+	if r.err == nil && (n == 0 || n-1 > uint64(len(body)-r.off)) {
+		return fmt.Errorf("automation: bad state count %d", n)
+	}
+	stateLen := int(n)
+	labels := make([]rune, stateLen)
+	outputs := make([]int32, stateLen)
+	fails := make([]int32, stateLen)
+	depths := make([]int32, stateLen)
+	firsts := make([]int32, 0, stateLen+1)
+	if stateLen > 0 {
+		outputs[0] = -1
+	}
+	for s := 1; s < stateLen && r.err == nil; s++ {
+		parent := r.id(s)
+		labels[s] = rune(r.varint())
+		outputs[s] = int32(r.varint())
+		fails[s] = int32(r.id(s))
+		if r.err != nil {
+			break
+		}
+		// 子状态编号连续，父状态编号不会减小
+		if parent < len(firsts)-1 {
+			return fmt.Errorf("automation: states not in BFS order")
+		}
+		for len(firsts) <= parent {
+			firsts = append(firsts, int32(s))
+		}
+		if outputs[s] < -1 || int(outputs[s]) >= dataLen {
+			return fmt.Errorf("automation: bad index %d", outputs[s])
+		}
+		depths[s] = depths[parent] + 1
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if r.off != len(body) {
+		return fmt.Errorf("automation: trailing data")
+	}
+	for len(firsts) <= stateLen {
+		firsts = append(firsts, int32(stateLen))
+	}
+
+	ac.root = node{index: -1}
+	ac.labels = labels
+	ac.outputs = outputs
+	ac.fails = fails
+	ac.depths = depths
+	ac.firsts = firsts
+	ac.maxDepth = 0
+	for _, d := range depths {
+		if int(d) > ac.maxDepth {
+			ac.maxDepth = int(d)
+		}
+	}
+	ac.buildGoto()
+	ac.buildDicts()
+	ac.datas = datas
+	ac.values = values
+	ac.bounds = bounds
+	ac.bounded = bounded
+	ac.multi = multi
+	ac.dataLen = dataLen
+	ac.compiled = true
+	if ac.pool == nil {
+		ac.pool = &sync.Pool{
+			New: newIndexesInfo,
+		}
+	}
+	return nil
+}
+
+func (ac *AutomationOf[V]) binaryFlags() uint16 {
+	var flags uint16
+	if len(ac.norms) > 0 {
+		flags |= binaryNormalized
+	}
+	if ac.skip != nil {
+		flags |= binarySkipped
+	}
+	return flags
+}
+
+// WriteTo 把序列化结果写入 w
+func (ac *AutomationOf[V]) WriteTo(w io.Writer) (int64, error) {
+	data, err := ac.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// ReadFrom 从 r 读取全部数据并恢复自动机
+func (ac *AutomationOf[V]) ReadFrom(r io.Reader) (int64, error) {
+	buff := &bytes.Buffer{}
+	n, err := io.Copy(buff, r)
+	if err != nil {
+		return n, err
+	}
+	return n, ac.UnmarshalBinary(buff.Bytes())
+}
+
+type binWriter struct {
+	buff bytes.Buffer
+	tmp  [binary.MaxVarintLen64]byte
+}
+
+func (w *binWriter) uint16(v uint16) {
+	binary.LittleEndian.PutUint16(w.tmp[:2], v)
+	w.buff.Write(w.tmp[:2])
+}
+
+func (w *binWriter) uint32(v uint32) {
+	binary.LittleEndian.PutUint32(w.tmp[:4], v)
+	w.buff.Write(w.tmp[:4])
+}
+
+func (w *binWriter) uvarint(v uint64) {
+	w.buff.Write(w.tmp[:binary.PutUvarint(w.tmp[:], v)])
+}
+
+func (w *binWriter) varint(v int64) {
+	w.buff.Write(w.tmp[:binary.PutVarint(w.tmp[:], v)])
+}
+
+func (w *binWriter) runes(rs []rune) {
+	w.uvarint(uint64(len(rs)))
+	for _, r := range rs {
+		w.varint(int64(r))
+	}
+}
+
+func (w *binWriter) bytes(b []byte) {
+	w.uvarint(uint64(len(b)))
+	w.buff.Write(b)
+}
+
+// binReader 读取出错后 err 保持不变，后续读取都返回零值
+type binReader struct {
+	data []byte
+	off  int
+	err  error
+}
+
+func (r *binReader) fail() {
+	if r.err == nil {
+		r.err = fmt.Errorf("automation: corrupted data at offset %d", r.off)
+	}
+}
+
+func (r *binReader) uint16() uint16 {
+	if r.err != nil || r.off+2 > len(r.data) {
+		r.fail()
+		return 0
+	}
+	v := binary.LittleEndian.Uint16(r.data[r.off:])
+	r.off += 2
+	return v
+}
+
+func (r *binReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data[r.off:])
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.off += n
+	return v
+}
+
+func (r *binReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.data[r.off:])
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.off += n
+	return v
+}
+
+// count 读取一个长度，长度不可能超过剩余字节数
+func (r *binReader) count() int {
+	v := r.uvarint()
+	if v > uint64(len(r.data)-r.off) {
+		r.fail()
+		return 0
+	}
+	return int(v)
+}
+
+// id 读取一个小于 limit 的节点编号
+func (r *binReader) id(limit int) int {
+	v := r.uvarint()
+	if v >= uint64(limit) {
+		r.fail()
+		return 0
+	}
+	return int(v)
+}
+
+func (r *binReader) runes() []rune {
+	n := r.count()
+	rs := make([]rune, n)
+	for i := range rs {
+		rs[i] = rune(r.varint())
+	}
+	return rs
+}
+
+func (r *binReader) bytes() []byte {
+	n := r.count()
+	if r.err != nil {
+		return nil
+	}
+	b := r.data[r.off : r.off+n]
+	r.off += n
+	return b
+}
@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Confusables 形近字符表，文本中的字符除了匹配它本身，还可以匹配表中它代替的任意一个模式串字符
+// 只作用于匹配的文本，模式串不做改动也不会展开，例如 '1' 可以代替 'l' 和 'i' 时，
+// 文本 "sh1t" 能命中模式串 "shit"，模式串 "110" 仍然只命中 "110"，不会命中 "llo"
+type Confusables map[rune][]rune
+
+// Add 添加 from 可以代替的字符，已有的不重复添加
+func (c Confusables) Add(from rune, to ...rune) Confusables {
+	for _, r := range to {
+		if r != from && !containsRune(c[from], r) {
+			c[from] = append(c[from], r)
+		}
+	}
+	return c
+}
+
+// SetConfusables 设置形近字符表，匹配时文本中的字符经过 Normalizer 之后再查表，nil 表示不使用
+// 不改变模式串，Insert 前后都可以设置
+func (ac *AutomationOf[V]) SetConfusables(c Confusables) *AutomationOf[V] {
+	if len(c) == 0 {
+		c = nil
+	}
+	ac.alts = c
+	return ac
+}
+
+// equiv 文本字符 r 能否匹配模式串字符 label
+func (ac *AutomationOf[V]) equiv(r, label rune) bool {
+	return r == label || containsRune(ac.alts[r], label)
+}
+
+// DefaultConfusables 内置的形近字符表，代替的目标都是小写拉丁字母，返回的是副本，可以用 Add 添加自定义的项
+// 收录了 Unicode TR39 confusables 中与拉丁字母形近的西里尔字母、希腊字母，以及常见的 leetspeak 写法，
+// 例如 "а"(西里尔)、"@"、"4" 可以代替 "a"，"0" 可以代替 "o"，"1" 可以代替 "l" 和 "i"；
+// 表中只有小写字母，一般和 DefaultNormalizer 一起使用
+func DefaultConfusables() Confusables {
+	c := Confusables{}
+	for _, pair := range strings.Fields(confusables) {
+		rs := []rune(pair)
+		c.Add(rs[0], rs[1])
+	}
+	return c
+}
+
+// LoadConfusables 读取 Unicode TR39 的 confusables.txt，
+// 只保留映射到单个字符的项，目标字符转为小写以便和 DefaultNormalizer 一起使用
+func LoadConfusables(r io.Reader) (Confusables, error) {
+	c := Confusables{}
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimPrefix(strings.TrimSpace(line), "\ufeff")
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, ";")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("confusables: line %d: want source ; target", lineNo)
+		}
+		from, err := parseCodePoints(fields[0])
+		if err != nil || len(from) != 1 {
+			return nil, fmt.Errorf("confusables: line %d: bad source %q", lineNo, fields[0])
+		}
+		to, err := parseCodePoints(fields[1])
+		if err != nil || len(to) == 0 {
+			return nil, fmt.Errorf("confusables: line %d: bad target %q", lineNo, fields[1])
+		}
+		if len(to) == 1 {
+			c.Add(from[0], unicode.ToLower(to[0]))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func containsRune(rs []rune, r rune) bool {
+	for _, x := range rs {
+		if x == r {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCodePoints 解析空白分隔的十六进制码点
+func parseCodePoints(s string) ([]rune, error) {
+	var rs []rune
+	for _, f := range strings.Fields(s) {
+		v, err := strconv.ParseUint(f, 16, 32)
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, rune(v))
+	}
+	return rs, nil
+}
+
+// confusables 每一项为“形近字符 它代替的拉丁字母”两个字符，同一个字符可以有多项
+const confusables = `
+аa еe ёe гr кk мm нh оo рp сc тt уy хx ѕs іi їi јj ԁd һh ԛq ԝw ӏl пn
+Аa Вb Еe Ёe Кk Мm Нh Оo Рp Сc Тt Уy Хx Ѕs Іi Їi Јj Ԁd Һh Ԛq Ԝw Ӏl
+αa εe ηn ιi κk νv οo ρp τt υu χx
+Αa Βb Εe Ζz Ηh Ιi Κk Μm Νn Οo Ρp Τt Υy Χx
+ıi ɩi ɑa ɡg ℓl ⅰi ⅼl ⅽc ⅾd ⅿm ⅴv ⅹx
+0o 1l 1i 3e 4a 5s 7t 8b 9g @a $s !i !l |l |i €e ¡i
+`
@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"sort"
+	"sync"
+)
+
+// Count 统计 seq 中每个模式串的命中次数，key 为模式串下标
+// 按照 SetMatchKind 设置的方式匹配，MatchStandard 时重叠的命中都计数；没有 Compile 时返回 ErrNotCompiled
+func (ac *AutomationOf[V]) Count(seq []rune) (map[int]int, error) {
+	return ac.count(runesInput[V](seq))
+}
+
+// MustCount 同 Count，出错时 panic
+func (ac *AutomationOf[V]) MustCount(seq []rune) map[int]int {
+	return mustCounts(ac.Count(seq))
+}
+
+// CountString 同 Count，直接处理 UTF-8 字符串
+func (ac *AutomationOf[V]) CountString(s string) (map[int]int, error) {
+	return ac.count(stringInput[V](s))
+}
+
+// MustCountString 同 CountString，出错时 panic
+func (ac *AutomationOf[V]) MustCountString(s string) map[int]int {
+	return mustCounts(ac.CountString(s))
+}
+
+func (ac *AutomationOf[V]) count(in input[V]) (map[int]int, error) {
+	if !ac.compiled {
+		return nil, ErrNotCompiled
+	}
+	counts := map[int]int{}
+	ac.matchFunc(in, func(h Hit[V]) bool {
+		counts[h.Index]++
+		return true
+	})
+	return counts, nil
+}
+
+func mustCounts(counts map[int]int, err error) map[int]int {
+	must(err)
+	return counts
+}
+
+// Counter 在多篇文档上累计每个模式串的命中次数，可以并发使用
+type Counter[V any] struct {
+	ac     *AutomationOf[V]
+	mu     sync.Mutex
+	counts []int // 模式串的命中次数，下标为模式串下标
+	docs   []int // 命中模式串的文档数
+	total  int   // 已经统计的文档数
+}
+
+// KeywordCount 模式串及其命中次数
+type KeywordCount[V any] struct {
+	Entry[V]
+	Count int // 命中次数
+	Docs  int // 命中的文档数
+}
+
+// NewCounter 创建计数器，没有 Compile 时返回 ErrNotCompiled
+func (ac *AutomationOf[V]) NewCounter() (*Counter[V], error) {
+	if !ac.compiled {
+		return nil, ErrNotCompiled
+	}
+	return &Counter[V]{
+		ac:     ac,
+		counts: make([]int, ac.dataLen),
+		docs:   make([]int, ac.dataLen),
+	}, nil
+}
+
+// MustNewCounter 同 NewCounter，出错时 panic
+func (ac *AutomationOf[V]) MustNewCounter() *Counter[V] {
+	c, err := ac.NewCounter()
+	must(err)
+	return c
+}
+
+// Add 统计一篇文档，匹配方式同 Count
+func (c *Counter[V]) Add(seq []rune) {
+	counts, _ := c.ac.Count(seq)
+	c.merge(counts)
+}
+
+// AddString 同 Add，直接处理 UTF-8 字符串
+func (c *Counter[V]) AddString(s string) {
+	counts, _ := c.ac.CountString(s)
+	c.merge(counts)
+}
+
+// merge 匹配在锁外进行，只有合并结果时加锁
+func (c *Counter[V]) merge(counts map[int]int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.total++
+	for index, n := range counts {
+		c.counts[index] += n
+		c.docs[index]++
+	}
+}
+
+// Docs 已经统计的文档数
+func (c *Counter[V]) Docs() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.total
+}
+
+// Get 下标为 index 的模式串的命中次数和命中的文档数，下标越界时返回 ErrBadIndex
+func (c *Counter[V]) Get(index int) (KeywordCount[V], error) {
+	if index < 0 || index >= len(c.counts) {
+		return KeywordCount[V]{}, ErrBadIndex
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.keyword(index), nil
+}
+
+func (c *Counter[V]) keyword(index int) KeywordCount[V] {
+	return KeywordCount[V]{
+		Entry: c.ac.entry(index),
+		Count: c.counts[index],
+		Docs:  c.docs[index],
+	}
+}
+
+// TopK 命中次数最多的 k 个模式串，k <= 0 时返回全部命中过的模式串
+// 按命中次数从多到少排列，次数相同时按模式串下标排列
+func (c *Counter[V]) TopK(k int) []KeywordCount[V] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var indexes []int
+	for index, n := range c.counts {
+		if n > 0 {
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Slice(indexes, func(a, b int) bool {
+		ca, cb := c.counts[indexes[a]], c.counts[indexes[b]]
+		if ca != cb {
+			return ca > cb
+		}
+		return indexes[a] < indexes[b]
+	})
+	if k > 0 && k < len(indexes) {
+		indexes = indexes[:k]
+	}
+	top := make([]KeywordCount[V], len(indexes))
+	for i, index := range indexes {
+		top[i] = c.keyword(index)
+	}
+	return top
+}
+
+// Reset 清空统计结果
+func (c *Counter[V]) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.counts {
+		c.counts[i], c.docs[i] = 0, 0
+	}
+	c.total = 0
+}
+
+// TotalsBy 按 key(value) 汇总命中次数，例如 value 为类别时得到每个类别的命中次数
+// DuplicateAccumulate 时模式串的每个 value 都计入
+func TotalsBy[V any, K comparable](c *Counter[V], key func(V) K) map[K]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	totals := map[K]int{}
+	for index, n := range c.counts {
+		if n == 0 {
+			continue
+		}
+		for _, v := range c.ac.valuesOf(index) {
+			totals[key(v)] += n
+		}
+	}
+	return totals
+}
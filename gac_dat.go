@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"sort"
+)
+
+// doubleArray 双数组形式的状态转移表
+// 字符先映射为编码 c（从 1 开始），状态 s 读入 c 时，若 check[base[s]+c] == s，
+// 则转移到 target[base[s]+c]；没有子状态的 base 为 0，
+// 它不是任何槽位的 check，因此不会误转移
+type doubleArray struct {
+	base   []int32
+	check  []int32
+	target []int32
+	codes  []int32        // BMP 字符的编码，0 表示字符不在字母表中
+	wide   map[rune]int32 // BMP 之外字符的编码
+	free   []int32        // 槽位分配用的并查集，free[i] 指向不小于 i 的空闲槽位
+	wideAt int32          // 多个子状态的节点从这里开始找 base
+}
+
+const (
+	bmpLen    = 0x10000
+	wideTries = 16
+)
+
+func (da *doubleArray) code(r rune) int32 {
+	if uint32(r) < uint32(len(da.codes)) {
+		return da.codes[r]
+	}
+	if r >= 0 && r < bmpLen {
+		return 0
+	}
+	return da.wide[r]
+}
+
+func (da *doubleArray) next(s int32, r rune) int32 {
+	c := da.code(r)
+	if c == 0 {
+		return -1
+	}
+	t := da.base[s] + c
+	if int(t) < len(da.check) && da.check[t] == s {
+		return da.target[t]
+	}
+	return -1
+}
+
+// buildDoubleArray 根据压平后的 trie 构建双数组
+func buildDoubleArray(labels []rune, firsts []int32) *doubleArray {
+	da := &doubleArray{
+		base: make([]int32, len(labels)),
+		wide: map[rune]int32{},
+	}
+	da.buildCodes(labels)
+
+	var codes []int32
+	for s := range da.base {
+		lo, hi := firsts[s], firsts[s+1]
+		if lo == hi {
+			continue
+		}
+		codes = codes[:0]
+		minCode := int32(-1)
+		for t := lo; t < hi; t++ {
+			c := da.code(labels[t])
+			codes = append(codes, c)
+			if minCode == -1 || c < minCode {
+				minCode = c
+			}
+		}
+		b := da.findBase(codes, minCode)
+		da.base[s] = b
+		for i, c := range codes {
+			da.use(b+c, int32(s), lo+int32(i))
+		}
+	}
+	da.check = da.check[:da.used()]
+	da.target = da.target[:len(da.check)]
+	da.free = nil
+	return da
+}
+
+// buildCodes 按出现次数从多到少分配字符编码，常用字符编码小，数组更紧凑
+func (da *doubleArray) buildCodes(labels []rune) {
+	freq := map[rune]int{}
+	for _, r := range labels[1:] {
+		freq[r]++
+	}
+	alphabet := make([]rune, 0, len(freq))
+	maxBMP := rune(-1)
+	for r := range freq {
+		alphabet = append(alphabet, r)
+		if r >= 0 && r < bmpLen && r > maxBMP {
+			maxBMP = r
+		}
+	}
+	sort.Slice(alphabet, func(i, j int) bool {
+		if freq[alphabet[i]] != freq[alphabet[j]] {
+			return freq[alphabet[i]] > freq[alphabet[j]]
+		}
+		return alphabet[i] < alphabet[j]
+	})
+	da.codes = make([]int32, maxBMP+1)
+	for i, r := range alphabet {
+		if r >= 0 && r < bmpLen {
+			da.codes[r] = int32(i + 1)
+		} else {
+			da.wide[r] = int32(i + 1)
+		}
+	}
+}
+
+// findBase 找到使所有 base+code 都空闲的 base
+// 只有一个子状态的节点总能放进第一个空位；多个子状态的节点在零散的空位上反复失败时，
+// 把起点挪到成功的位置，前面的空位留给单个子状态的节点
+func (da *doubleArray) findBase(codes []int32, minCode int32) int32 {
+	from := minCode
+	if len(codes) > 1 && da.wideAt > from {
+		from = da.wideAt
+	}
+	tries := 0
+	for pos := da.findFree(from); ; pos = da.findFree(pos + 1) {
+		b := pos - minCode
+		ok := true
+		for _, c := range codes {
+			if !da.isFree(b + c) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			if len(codes) > 1 && tries > wideTries {
+				da.wideAt = pos
+			}
+			return b
+		}
+		tries++
+	}
+}
+
+func (da *doubleArray) grow(size int32) {
+	for int32(len(da.check)) < size {
+		da.check = append(da.check, -1)
+		da.target = append(da.target, -1)
+		da.free = append(da.free, int32(len(da.free)))
+	}
+}
+
+func (da *doubleArray) isFree(i int32) bool {
+	da.grow(i + 1)
+	return da.check[i] == -1
+}
+
+// findFree 返回不小于 i 的第一个空闲槽位，顺带压缩路径
+func (da *doubleArray) findFree(i int32) int32 {
+	da.grow(i + 1)
+	root := i
+	for da.free[root] != root {
+		root = da.free[root]
+		da.grow(root + 1)
+	}
+	for da.free[i] != root {
+		i, da.free[i] = da.free[i], root
+	}
+	return root
+}
+
+func (da *doubleArray) use(i, owner, target int32) {
+	da.grow(i + 2)
+	da.check[i] = owner
+	da.target[i] = target
+	da.free[i] = i + 1
+}
+
+// used 最后一个被占用的槽位之后的长度
+func (da *doubleArray) used() int {
+	n := len(da.check)
+	for n > 0 && da.check[n-1] == -1 {
+		n--
+	}
+	return n
+}
@@ -0,0 +1,186 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unsafe"
+)
+
+// Stats Compile 之后自动机的统计信息
+type Stats struct {
+	Nodes     int     // 状态数，包括根状态
+	Patterns  int     // 模式串数，重复插入的模式串只计一次
+	MaxDepth  int     // 最长模式串的长度
+	AvgFanout float64 // 有子状态的状态平均有多少个子状态
+	Memory    int     // 估计占用的字节数，不包括 value 引用的内存
+}
+
+// Stats 返回自动机的统计信息，没有 Compile 时返回 ErrNotCompiled
+func (ac *AutomationOf[V]) Stats() (Stats, error) {
+	if !ac.compiled {
+		return Stats{}, ErrNotCompiled
+	}
+	st := Stats{
+		Nodes:    len(ac.labels),
+		Patterns: ac.dataLen,
+		MaxDepth: ac.maxDepth,
+		Memory:   ac.memory(),
+	}
+	inner := 0
+	for s := range ac.labels {
+		if ac.firsts[s+1] > ac.firsts[s] {
+			inner++
+		}
+	}
+	if inner > 0 {
+		st.AvgFanout = float64(len(ac.labels)-1) / float64(inner)
+	}
+	return st, nil
+}
+
+// MustStats 同 Stats，出错时 panic
+func (ac *AutomationOf[V]) MustStats() Stats {
+	st, err := ac.Stats()
+	must(err)
+	return st
+}
+
+// memory 按切片长度和 map 的桶数估计占用的字节数
+func (ac *AutomationOf[V]) memory() int {
+	const header = int(unsafe.Sizeof([]int32(nil)))
+	n := 4 * (len(ac.labels) + len(ac.fails) + len(ac.dicts) + len(ac.outputs) +
+		len(ac.depths) + len(ac.firsts) + len(ac.roots))
+	for _, m := range ac.children {
+		if m != nil {
+			n += mapMemory(len(m), 4, 4)
+		}
+	}
+	if da := ac.dat; da != nil {
+		n += 4 * (len(da.base) + len(da.check) + len(da.target) + len(da.codes))
+		n += mapMemory(len(da.wide), 4, 4)
+	}
+	var zero V
+	size := int(unsafe.Sizeof(zero))
+	for _, data := range ac.datas {
+		n += header + 4*len(data)
+	}
+	n += size*len(ac.values) + len(ac.bounds)
+	for _, vs := range ac.multi {
+		n += header + size*len(vs)
+	}
+	return n + mapMemory(len(ac.multi), 8, header)
+}
+
+// mapMemory 估计有 n 个元素的 map 占用的字节数，每个桶 8 个元素，平均装载 6.5 个
+func mapMemory(n, keySize, valueSize int) int {
+	if n == 0 {
+		return 0
+	}
+	buckets := 1
+	for float64(n) > 6.5*float64(buckets) {
+		buckets *= 2
+	}
+	return 48 + buckets*(8+8*keySize+8*valueSize+8)
+}
+
+// WriteDOT 以 Graphviz DOT 格式输出自动机，用于调试规模较小的自动机
+// 实线为 goto 转移，虚线为 fail 指针（省略指向根状态的），双圈为模式串结尾的状态
+// 没有 Compile 时返回 ErrNotCompiled
+func (ac *AutomationOf[V]) WriteDOT(w io.Writer) error {
+	if !ac.compiled {
+		return ErrNotCompiled
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph automation {")
+	fmt.Fprintln(bw, "\trankdir=LR;")
+	fmt.Fprintln(bw, "\tnode [shape=circle];")
+	for s := range ac.labels {
+		if index := ac.outputs[s]; index >= 0 {
+			label := fmt.Sprintf("%d\n#%d %s", s, index, string(ac.datas[index]))
+			fmt.Fprintf(bw, "\t%d [shape=doublecircle, label=%s];\n", s, dotQuote(label))
+		} else {
+			fmt.Fprintf(bw, "\t%d;\n", s)
+		}
+	}
+	for s := range ac.labels {
+		for t := ac.firsts[s]; t < ac.firsts[s+1]; t++ {
+			fmt.Fprintf(bw, "\t%d -> %d [label=%s];\n", s, t, dotQuote(string(ac.labels[t])))
+		}
+	}
+	for s := 1; s < len(ac.labels); s++ {
+		if f := ac.fails[s]; f != 0 {
+			fmt.Fprintf(bw, "\t%d -> %d [style=dashed, color=gray];\n", s, f)
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotEscaper DOT 字符串中需要转义的字符，\n 为居中的换行
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
+
+// MustWriteDOT 同 WriteDOT，出错时 panic
+func (ac *AutomationOf[V]) MustWriteDOT(w io.Writer) {
+	must(ac.WriteDOT(w))
+}
+
+type dumpAutomation struct {
+	Stats Stats       `json:"stats"`
+	Nodes []dumpState `json:"nodes"`
+}
+
+type dumpState struct {
+	ID      int        `json:"id"`
+	Depth   int        `json:"depth"`
+	Fail    int        `json:"fail"`
+	Output  int        `json:"output"`            // 沿 fail 链最近的模式串结尾状态，0 表示没有
+	Index   int        `json:"index"`             // 模式串下标，不是模式串结尾时为 -1
+	Pattern string     `json:"pattern,omitempty"` // 插入时的模式串
+	Edges   []dumpEdge `json:"edges,omitempty"`
+}
+
+type dumpEdge struct {
+	Label string `json:"label"`
+	To    int    `json:"to"`
+}
+
+// DumpJSON 以 JSON 格式输出统计信息和全部状态，包括 goto 转移、fail 指针、输出链接和模式串结尾
+// 用于调试规模较小的自动机，不包括 value；没有 Compile 时返回 ErrNotCompiled
+func (ac *AutomationOf[V]) DumpJSON(w io.Writer) error {
+	st, err := ac.Stats()
+	if err != nil {
+		return err
+	}
+	dump := dumpAutomation{Stats: st, Nodes: make([]dumpState, len(ac.labels))}
+	for s := range ac.labels {
+		ds := dumpState{
+			ID:     s,
+			Depth:  int(ac.depths[s]),
+			Fail:   int(ac.fails[s]),
+			Output: int(ac.dicts[s]),
+			Index:  int(ac.outputs[s]),
+		}
+		if ds.Index >= 0 {
+			ds.Pattern = string(ac.datas[ds.Index])
+		}
+		for t := ac.firsts[s]; t < ac.firsts[s+1]; t++ {
+			ds.Edges = append(ds.Edges, dumpEdge{Label: string(ac.labels[t]), To: int(t)})
+		}
+		dump.Nodes[s] = ds
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(dump)
+}
+
+// MustDumpJSON 同 DumpJSON，出错时 panic
+func (ac *AutomationOf[V]) MustDumpJSON(w io.Writer) {
+	must(ac.DumpJSON(w))
+}
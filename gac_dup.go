@@ -0,0 +1,62 @@
+package tools
+
+// DuplicatePolicy 插入归一化之后相同的模式串时的处理方式
+type DuplicatePolicy int
+
+const (
+	DuplicateReplace    DuplicatePolicy = iota // 替换之前的模式串和 value，默认
+	DuplicateKeepFirst                         // 保留第一次插入的模式串和 value，忽略之后的
+	DuplicateAccumulate                        // 保留第一次插入的模式串，累积全部 value，命中时通过 Hit.Values 获取
+)
+
+// SetDuplicatePolicy 设置重复模式串的处理方式，对之后的 Insert 生效
+// 重复的模式串不会占用新的下标，也不计入 GetMatched 的下标范围
+func (ac *AutomationOf[V]) SetDuplicatePolicy(policy DuplicatePolicy) *AutomationOf[V] {
+	ac.dup = policy
+	return ac
+}
+
+// duplicate 按照 DuplicatePolicy 处理与下标为 index 的模式串重复的插入
+func (ac *AutomationOf[V]) duplicate(index int, data []rune, value V, key []rune, word bool) {
+	switch ac.dup {
+	case DuplicateKeepFirst:
+	case DuplicateAccumulate:
+		if ac.multi == nil {
+			ac.multi = map[int][]V{}
+		}
+		if _, ok := ac.multi[index]; !ok {
+			ac.multi[index] = []V{ac.values[index]}
+		}
+		ac.multi[index] = append(ac.multi[index], value)
+	default:
+		ac.datas[index] = data
+		ac.values[index] = value
+		ac.bounds[index] = wordBounds(key, word)
+		ac.bounded = ac.bounded || ac.bounds[index] != 0
+		delete(ac.multi, index)
+	}
+}
+
+// valuesOf 下标为 index 的模式串的全部 value，不能修改
+func (ac *AutomationOf[V]) valuesOf(index int) []V {
+	if vs, ok := ac.multi[index]; ok {
+		return vs
+	}
+	return ac.values[index : index+1 : index+1]
+}
+
+// GetMatchedAll 下标为 index 的模式串及其全部 value，下标越界时返回 ErrBadIndex
+// 只有 DuplicateAccumulate 时才可能有多个 value，返回的切片不能修改
+func (ac *AutomationOf[V]) GetMatchedAll(index int) ([]rune, []V, error) {
+	if index < 0 || index >= ac.dataLen {
+		return nil, nil, ErrBadIndex
+	}
+	return ac.datas[index], ac.valuesOf(index), nil
+}
+
+// MustGetMatchedAll 同 GetMatchedAll，出错时 panic
+func (ac *AutomationOf[V]) MustGetMatchedAll(index int) ([]rune, []V) {
+	data, values, err := ac.GetMatchedAll(index)
+	must(err)
+	return data, values
+}
@@ -0,0 +1,309 @@
+package tools
+
+import (
+	"sort"
+)
+
+// FuzzyOptions 近似匹配的参数
+type FuzzyOptions struct {
+	MaxDistance int // 最大编辑距离（Levenshtein），一般为 1 或 2，越大越慢
+	// 归一化之后长度小于 MinLength 的模式串只做精确匹配，避免短词大量误报，
+	// 0 表示 2*MaxDistance+1，即只有长度超过 2*MaxDistance 的模式串才做近似匹配
+	MinLength int
+	// 不为 nil 时按模式串下标分别返回每个模式串的 MinLength，代替 MinLength
+	MinLengthOf func(index int) int
+}
+
+// MatchFuzzy 近似匹配，找出与 seq 的某个子串编辑距离不超过 MaxDistance 的模式串，
+// Hit.Distance 为编辑距离；同一模式串重叠的命中只保留编辑距离最小的一个，命中按结束位置排序
+// 命中的第一个字符一定与模式串前 MaxDistance+1 个字符中的某一个相同，以第一个字符被替换开头的命中
+// 总可以换成从下一个字符开始、编辑距离相同的命中
+// 使用与 Match 相同的 trie 和 Normalizer，噪声字符直接跳过，不受 MatchKind 影响
+func (ac *AutomationOf[V]) MatchFuzzy(seq []rune, opts FuzzyOptions) ([]Hit[V], error) {
+	if !ac.compiled {
+		return nil, ErrNotCompiled
+	}
+	ft := ac.newFuzzyText()
+	for _, r := range seq {
+		ft.add(r, runeSize(r))
+	}
+	return ac.fuzzy(ft, opts), nil
+}
+
+// MustMatchFuzzy 同 MatchFuzzy，出错时 panic
+func (ac *AutomationOf[V]) MustMatchFuzzy(seq []rune, opts FuzzyOptions) []Hit[V] {
+	return mustHits(ac.MatchFuzzy(seq, opts))
+}
+
+// MatchFuzzyString 同 MatchFuzzy，s 为 UTF-8 编码的文本
+func (ac *AutomationOf[V]) MatchFuzzyString(s string, opts FuzzyOptions) ([]Hit[V], error) {
+	if !ac.compiled {
+		return nil, ErrNotCompiled
+	}
+	ft := ac.newFuzzyText()
+	eachString(s, ft.add)
+	return ac.fuzzy(ft, opts), nil
+}
+
+// MustMatchFuzzyString 同 MatchFuzzyString，出错时 panic
+func (ac *AutomationOf[V]) MustMatchFuzzyString(s string, opts FuzzyOptions) []Hit[V] {
+	return mustHits(ac.MatchFuzzyString(s, opts))
+}
+
+func mustHits[V any](hits []Hit[V], err error) []Hit[V] {
+	must(err)
+	return hits
+}
+
+// fuzzyText 归一化之后的待匹配文本，units[i] 对应原始文本中 [starts[i], ends[i]) 的字符
+type fuzzyText struct {
+	norms   []Normalizer
+	skip    SkipSet
+	runes   []rune // 原始文本，用来检查单词边界
+	units   []rune
+	starts  []origin
+	ends    []origin
+	pos     int
+	bytePos int
+	cur     []rune
+	tmp     []rune
+}
+
+func (ac *AutomationOf[V]) newFuzzyText() *fuzzyText {
+	return &fuzzyText{norms: ac.norms, skip: ac.skip}
+}
+
+func (ft *fuzzyText) add(r rune, size int) bool {
+	start := origin{pos: ft.pos, bytePos: ft.bytePos}
+	ft.pos++
+	ft.bytePos += size
+	end := origin{pos: ft.pos, bytePos: ft.bytePos}
+	ft.runes = append(ft.runes, r)
+	ft.cur, ft.tmp = normalizeRune(ft.norms, r, ft.cur, ft.tmp)
+	for _, c := range ft.cur {
+		if ft.skip != nil && ft.skip(c) {
+			continue
+		}
+		ft.units = append(ft.units, c)
+		ft.starts = append(ft.starts, start)
+		ft.ends = append(ft.ends, end)
+	}
+	return true
+}
+
+// fuzzer 命中的第一个字符 units[start] 必须与模式串的某个字符相同（锚点），锚点之前的模式串字符都被删除，
+// 因此锚点只能在前 k+1 层；从每个锚点状态开始深度优先遍历 trie，rows[d] 为深度 d 的状态对应的动态规划行：
+// rows[d][j] 为该状态的模式串前缀与 units[start:start+j] 在 units[start] 对齐到锚点时的编辑距离，
+// 只计算 j <= his[d] 的部分，更长的子串编辑距离一定超过 k
+type fuzzer[V any] struct {
+	ac      *AutomationOf[V]
+	text    *fuzzyText
+	k       int
+	opts    FuzzyOptions
+	anchors map[rune][]int32 // 前 k+1 层的状态按字符分组
+	start   int
+	width   int // 从 start 开始最多还有多少个字符
+	rows    [][]int
+	his     []int
+	hits    []Hit[V]
+}
+
+func (ac *AutomationOf[V]) fuzzy(ft *fuzzyText, opts FuzzyOptions) []Hit[V] {
+	k := opts.MaxDistance
+	if k < 0 {
+		k = 0
+	}
+	fz := &fuzzer[V]{
+		ac:      ac,
+		text:    ft,
+		k:       k,
+		opts:    opts,
+		anchors: map[rune][]int32{},
+		rows:    make([][]int, ac.maxDepth+1),
+		his:     make([]int, ac.maxDepth+1),
+	}
+	for d := range fz.rows {
+		fz.rows[d] = make([]int, d+k+1)
+	}
+	// 状态按 BFS 顺序编号，前 k+1 层是开头的一段
+	for t := 1; t < len(ac.labels) && int(ac.depths[t]) <= k+1; t++ {
+		fz.anchors[ac.labels[t]] = append(fz.anchors[ac.labels[t]], int32(t))
+	}
+	for fz.start = 0; fz.start < len(ft.units); fz.start++ {
+		fz.width = len(ft.units) - fz.start
+		c := ft.units[fz.start]
+		fz.seed(fz.anchors[c])
+		for _, a := range ac.alts[c] {
+			fz.seed(fz.anchors[a])
+		}
+	}
+	return fz.selectHits()
+}
+
+// seed 以 anchors 中的状态为锚点开始遍历，深度为 d 的锚点之前删除了 d-1 个模式串字符
+func (fz *fuzzer[V]) seed(anchors []int32) {
+	for _, t := range anchors {
+		d := int(fz.ac.depths[t])
+		row, hi := fz.rows[d], minInt(d+fz.k, fz.width)
+		fz.his[d] = hi
+		row[0] = fz.k + 1
+		for j := 1; j <= hi; j++ {
+			row[j] = d + j - 2
+		}
+		if fz.ac.outputs[t] >= 0 {
+			fz.report(t, d)
+		}
+		fz.walk(t)
+	}
+}
+
+func (fz *fuzzer[V]) walk(s int32) {
+	ac := fz.ac
+	for t := ac.firsts[s]; t < ac.firsts[s+1]; t++ {
+		d := int(ac.depths[t])
+		prev, prevHi := fz.rows[d-1], fz.his[d-1]
+		row, hi := fz.rows[d], minInt(d+fz.k, fz.width)
+		fz.his[d] = hi
+		// 没有读入文本时 units[start] 还没有对齐到锚点，不算
+		row[0] = fz.k + 1
+		best := row[0]
+		for j := 1; j <= hi; j++ {
+			v := row[j-1] + 1
+			if j <= prevHi {
+				v = minInt(v, prev[j]+1)
+			}
+			if j-1 <= prevHi {
+				cost := 1
+				if ac.equiv(fz.text.units[fz.start+j-1], ac.labels[t]) {
+					cost = 0
+				}
+				v = minInt(v, prev[j-1]+cost)
+			}
+			row[j] = v
+			best = minInt(best, v)
+		}
+		if best > fz.k {
+			continue
+		}
+		if ac.outputs[t] >= 0 {
+			fz.report(t, d)
+		}
+		fz.walk(t)
+	}
+}
+
+// report 状态 t 是模式串结尾时，选择编辑距离最小的结束位置，相同时选长度最接近模式串的
+func (fz *fuzzer[V]) report(t int32, d int) {
+	row, hi := fz.rows[d], fz.his[d]
+	index := fz.ac.outputs[t]
+	if !fz.boundAt(int(index), 0) {
+		return
+	}
+	end := -1
+	if d < fz.minLength(int(index)) {
+		if d <= hi && row[d] == 0 && fz.boundAt(int(index), d) {
+			end = d
+		}
+	} else {
+		for j := 1; j <= hi; j++ {
+			if row[j] > fz.k || !fz.boundAt(int(index), j) {
+				continue
+			}
+			if end == -1 || row[j] < row[end] || row[j] == row[end] && abs(j-d) < abs(end-d) {
+				end = j
+			}
+		}
+	}
+	if end == -1 {
+		return
+	}
+	start, stop := fz.text.starts[fz.start], fz.text.ends[fz.start+end-1]
+	fz.hits = append(fz.hits, Hit[V]{
+		Index:     int(index),
+		Start:     start.pos,
+		End:       stop.pos,
+		ByteStart: start.bytePos,
+		ByteEnd:   stop.bytePos,
+		Distance:  row[end],
+		Value:     fz.ac.values[index],
+		Values:    fz.ac.valuesOf(int(index)),
+	})
+}
+
+// boundAt 下标为 index 的模式串要求单词边界时，检查 units[start] 之前（j == 0）
+// 或 units[start+j-1] 之后的原始字符是否不是单词字符
+func (fz *fuzzer[V]) boundAt(index, j int) bool {
+	ac := fz.ac
+	if !ac.bounded {
+		return true
+	}
+	runes := fz.text.runes
+	if j == 0 {
+		p := fz.text.starts[fz.start].pos
+		return ac.bounds[index]&boundStart == 0 || p == 0 || !isWordRune(runes[p-1])
+	}
+	p := fz.text.ends[fz.start+j-1].pos
+	return ac.bounds[index]&boundEnd == 0 || p == len(runes) || !isWordRune(runes[p])
+}
+
+// minLength 下标为 index 的模式串做近似匹配的最小长度
+func (fz *fuzzer[V]) minLength(index int) int {
+	n := fz.opts.MinLength
+	if fz.opts.MinLengthOf != nil {
+		n = fz.opts.MinLengthOf(index)
+	}
+	if n == 0 {
+		n = 2*fz.k + 1
+	}
+	return n
+}
+
+// selectHits 同一模式串的命中按编辑距离从小到大选择，和已选中的命中重叠的丢弃
+func (fz *fuzzer[V]) selectHits() []Hit[V] {
+	hits := fz.hits
+	sort.SliceStable(hits, func(a, b int) bool {
+		if hits[a].Distance != hits[b].Distance {
+			return hits[a].Distance < hits[b].Distance
+		}
+		return hits[a].Start < hits[b].Start
+	})
+	chosen := map[int][]Hit[V]{}
+	result := make([]Hit[V], 0, len(hits))
+	for _, h := range hits {
+		overlap := false
+		for _, c := range chosen[h.Index] {
+			if h.Start < c.End && c.Start < h.End {
+				overlap = true
+				break
+			}
+		}
+		if !overlap {
+			chosen[h.Index] = append(chosen[h.Index], h)
+			result = append(result, h)
+		}
+	}
+	sort.Slice(result, func(a, b int) bool {
+		if result[a].End != result[b].End {
+			return result[a].End < result[b].End
+		}
+		if result[a].Start != result[b].Start {
+			return result[a].Start > result[b].Start
+		}
+		return result[a].Index < result[b].Index
+	})
+	return result
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,173 @@
+package tools
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// HotAutomationOf 支持热更新的AC自动机
+// 任何时候都可以 Insert/Remove，后台协程重新构建 Automation 并原子替换；
+// 新的快照发布之前，并发的 Match 始终看到旧的一致快照
+type HotAutomationOf[V any] struct {
+	mu      sync.Mutex
+	buildMu sync.Mutex
+	gen     func() *AutomationOf[V]
+	entries []hotEntry[V]
+	keys    map[string]int
+	removed int
+	current atomic.Value // *AutomationOf[V]
+	notify  chan struct{}
+	closed  chan struct{}
+	once    sync.Once
+}
+
+type hotEntry[V any] struct {
+	data    []rune
+	value   V
+	removed bool
+}
+
+// HotAutomation value 为 interface{} 的热更新AC自动机
+type HotAutomation = HotAutomationOf[interface{}]
+
+// GenHotAutomation 创建支持热更新的AC自动机
+// gen 用来创建每次重建时使用的空 Automation，为 nil 时使用 GenAutomation
+func GenHotAutomation(gen func() *Automation) *HotAutomation {
+	return GenHotAutomationOf(gen)
+}
+
+// GenHotAutomationOf 同 GenHotAutomation，gen 为 nil 时使用 GenAutomationOf[V]
+func GenHotAutomationOf[V any](gen func() *AutomationOf[V]) *HotAutomationOf[V] {
+	if gen == nil {
+		gen = GenAutomationOf[V]
+	}
+	h := &HotAutomationOf[V]{
+		gen:    gen,
+		keys:   map[string]int{},
+		notify: make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+	empty := gen()
+	empty.Compile()
+	h.current.Store(empty)
+	go h.loop()
+	return h
+}
+
+// Insert 插入或替换模式串，变更在后台重建完成后生效
+func (h *HotAutomationOf[V]) Insert(data []rune, value V) {
+	if len(data) == 0 {
+		return
+	}
+	key := string(data)
+	h.mu.Lock()
+	if i, ok := h.keys[key]; ok {
+		h.entries[i].value = value
+	} else {
+		h.keys[key] = len(h.entries)
+		h.entries = append(h.entries, hotEntry[V]{data: []rune(key), value: value})
+	}
+	h.mu.Unlock()
+	h.schedule()
+}
+
+// Remove 删除模式串，不存在时返回 false
+func (h *HotAutomationOf[V]) Remove(data []rune) bool {
+	key := string(data)
+	h.mu.Lock()
+	i, ok := h.keys[key]
+	if ok {
+		delete(h.keys, key)
+		h.entries[i] = hotEntry[V]{removed: true}
+		h.removed++
+	}
+	h.mu.Unlock()
+	if ok {
+		h.schedule()
+	}
+	return ok
+}
+
+// Len 当前模式串数量（包含尚未发布的变更）
+func (h *HotAutomationOf[V]) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.keys)
+}
+
+// Load 返回当前已发布的快照
+// 同一次匹配的 Match、GetMatched、PoolPut 都应该在同一个快照上调用
+func (h *HotAutomationOf[V]) Load() *AutomationOf[V] {
+	return h.current.Load().(*AutomationOf[V])
+}
+
+// Rebuild 同步重建并发布快照，返回时之前的所有变更都已生效
+// gen 返回的 Automation 已经 Compile 时返回 ErrCompiled，快照保持不变
+func (h *HotAutomationOf[V]) Rebuild() error {
+	h.buildMu.Lock()
+	defer h.buildMu.Unlock()
+
+	// 只在复制条目时持有 mu，构建期间 Insert/Remove 不会被阻塞
+	h.mu.Lock()
+	if h.removed > len(h.entries)/2 {
+		h.compact()
+	}
+	entries := make([]hotEntry[V], 0, len(h.keys))
+	for _, e := range h.entries {
+		if !e.removed {
+			entries = append(entries, e)
+		}
+	}
+	h.mu.Unlock()
+
+	ac := h.gen()
+	for _, e := range entries {
+		if err := ac.Insert(e.data, e.value); err != nil {
+			return err
+		}
+	}
+	if err := ac.Compile(); err != nil {
+		return err
+	}
+	h.current.Store(ac)
+	return nil
+}
+
+// Close 停止后台重建协程，已发布的快照仍然可用，之后的变更需要调用 Rebuild 才会生效
+func (h *HotAutomationOf[V]) Close() {
+	h.once.Do(func() {
+		close(h.closed)
+	})
+}
+
+func (h *HotAutomationOf[V]) schedule() {
+	select {
+	case h.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (h *HotAutomationOf[V]) loop() {
+	for {
+		select {
+		case <-h.notify:
+			// 出错时保留旧的快照，错误可以通过同步调用 Rebuild 获得
+			_ = h.Rebuild()
+		case <-h.closed:
+			return
+		}
+	}
+}
+
+// compact 去掉已删除的条目，调用方需要持有 mu
+func (h *HotAutomationOf[V]) compact() {
+	entries := make([]hotEntry[V], 0, len(h.keys))
+	for _, e := range h.entries {
+		if !e.removed {
+			h.keys[string(e.data)] = len(entries)
+			entries = append(entries, e)
+		}
+	}
+	h.entries = entries
+	h.removed = 0
+}
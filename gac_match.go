@@ -0,0 +1,492 @@
+package tools
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// MatchKind 匹配方式
+type MatchKind int
+
+const (
+	MatchStandard        MatchKind = iota // 报告所有命中，包括重叠的命中，默认
+	MatchLeftmostFirst                    // 命中不重叠，起始位置最靠左，同一起始位置先插入的优先
+	MatchLeftmostLongest                  // 命中不重叠，起始位置最靠左，同一起始位置最长的优先
+)
+
+// matchUnion 内部使用的匹配方式：把重叠的命中合并为一个区间，用于 Mask
+const matchUnion MatchKind = -1
+
+// endOfInput 输入结束时的位置，比任何真实位置都大
+const endOfInput = int(^uint(0) >> 1)
+
+// Hit 一次命中，seq[Start:End] 即命中的内容，
+// 对于 UTF-8 文本 s[ByteStart:ByteEnd] 即命中的内容
+type Hit[V any] struct {
+	Index     int // 模式串下标，通过 GetMatched 获取模式串
+	Start     int // 第一个字符的位置
+	End       int // 最后一个字符之后的位置
+	ByteStart int // 第一个字符的字节位置
+	ByteEnd   int // 最后一个字符之后的字节位置
+	Distance  int // 与模式串的编辑距离，只有 MatchFuzzy 的命中不为 0
+	Value     V   // 模式串的 value
+	Values    []V // 模式串的全部 value，只有 DuplicateAccumulate 时可能有多个，不能修改
+}
+
+// SetMatchKind 设置匹配方式，默认 MatchStandard
+func (ac *AutomationOf[V]) SetMatchKind(kind MatchKind) *AutomationOf[V] {
+	ac.kind = kind
+	return ac
+}
+
+// scanner 一次匹配的状态
+// 每个原始字符经过 Normalizer 之后变为零个或多个字符再读入自动机，
+// 命中的位置都是原始文本中的位置；字节位置按 UTF-8 编码计算，无法编码的字符按 utf8.RuneError 计算
+type scanner[V any] struct {
+	ac       *AutomationOf[V]
+	fn       func(Hit[V]) bool
+	lm       *leftmost[V] // 不是 MatchStandard 时用来选择不重叠的命中
+	state    int32
+	states   []int32 // 有形近字符表时同时跟踪的全部状态，取代 state
+	nexts    []int32
+	outs     []int32
+	pos      int      // 已读入的原始字符数
+	bytePos  int      // 已读入的原始字节数
+	units    int      // 已读入自动机的字符数
+	gap      int      // 上一个读入自动机的字符之后跳过的字符数
+	origins  []origin // 最近 maxDepth 个读入自动机的字符的原始位置，第 i 个在 origins[i%len(origins)]
+	last     rune     // 上一个原始字符，没有时为 -1
+	deferred []Hit[V] // 有模式串要求单词边界时，等下一个原始字符到达再确定的命中
+	cur      []rune   // 归一化使用的临时空间
+	tmp      []rune
+}
+
+// origin 读入自动机的字符对应的原始字符的起始位置，prev 为它之前的原始字符
+type origin struct {
+	pos     int
+	bytePos int
+	prev    rune
+}
+
+func (ac *AutomationOf[V]) newScanner(kind MatchKind, fn func(Hit[V]) bool) *scanner[V] {
+	depth := ac.maxDepth
+	if depth == 0 {
+		depth = 1
+	}
+	sc := &scanner[V]{ac: ac, fn: fn, origins: make([]origin, depth), last: -1}
+	if ac.alts != nil {
+		sc.states = []int32{0}
+	}
+	if kind != MatchStandard {
+		sc.lm = &leftmost[V]{kind: kind}
+	}
+	return sc
+}
+
+func (sc *scanner[V]) reset() {
+	sc.state = 0
+	if sc.states != nil {
+		sc.states = append(sc.states[:0], 0)
+	}
+	sc.pos = 0
+	sc.bytePos = 0
+	sc.units = 0
+	sc.gap = 0
+	sc.last = -1
+	sc.deferred = sc.deferred[:0]
+	if sc.lm != nil {
+		sc.lm = &leftmost[V]{kind: sc.lm.kind}
+	}
+}
+
+// feed 读入占 size 个字节的原始字符 r，fn 返回 false 时停止并返回 false
+func (sc *scanner[V]) feed(r rune, size int) bool {
+	if len(sc.deferred) > 0 && !sc.release(isWordRune(r)) {
+		return false
+	}
+	o := origin{pos: sc.pos, bytePos: sc.bytePos, prev: sc.last}
+	sc.last = r
+	sc.pos++
+	sc.bytePos += size
+	if len(sc.ac.norms) == 0 {
+		if !sc.unit(r, o) {
+			return false
+		}
+	} else {
+		sc.cur, sc.tmp = normalizeRune(sc.ac.norms, r, sc.cur, sc.tmp)
+		for _, c := range sc.cur {
+			if !sc.unit(c, o) {
+				return false
+			}
+		}
+	}
+	if sc.lm != nil {
+		return sc.lm.flush(sc.minStart(), sc.fn)
+	}
+	return true
+}
+
+// unit 自动机读入一个字符，沿输出链接报告以它结尾的所有命中
+// 噪声字符不读入自动机，连续跳过的字符超过 maxGap 时回到根状态，命中不能跨过这段噪声
+func (sc *scanner[V]) unit(r rune, o origin) bool {
+	ac := sc.ac
+	if ac.skip != nil {
+		if ac.skip(r) {
+			sc.gap++
+			return true
+		}
+		if ac.maxGap > 0 && sc.gap > ac.maxGap {
+			sc.state = 0
+			if sc.states != nil {
+				sc.states = append(sc.states[:0], 0)
+			}
+		}
+		sc.gap = 0
+	}
+	sc.origins[sc.units%len(sc.origins)] = o
+	sc.units++
+	if sc.states != nil {
+		return sc.confusable(r)
+	}
+	sc.state = ac.step(sc.state, r)
+	s := sc.state
+	if ac.outputs[s] == -1 {
+		s = ac.dicts[s]
+	}
+	for ; s > 0; s = ac.dicts[s] {
+		if !sc.hit(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// confusable 有形近字符表时 r 可以代表它本身和表中的任意一个字符，
+// 同时跟踪每一种读法到达的状态，模式串不需要展开；
+// 根状态能匹配的其他状态也都能匹配，有其他状态时不保留根状态
+func (sc *scanner[V]) confusable(r rune) bool {
+	ac := sc.ac
+	alts := ac.alts[r]
+	next := sc.nexts[:0]
+	for _, s := range sc.states {
+		next = addState(next, ac.step(s, r))
+		for _, c := range alts {
+			next = addState(next, ac.step(s, c))
+		}
+	}
+	if len(next) == 0 {
+		next = append(next, 0)
+	}
+	sc.states, sc.nexts = next, sc.states
+	// 不同状态的输出链接可能汇合，每个模式串只报告一次，同一结束位置长的在前
+	outs := sc.outs[:0]
+	for _, s := range next {
+		if ac.outputs[s] == -1 {
+			s = ac.dicts[s]
+		}
+		for ; s > 0 && !containsState(outs, s); s = ac.dicts[s] {
+			outs = append(outs, s)
+		}
+	}
+	if len(outs) > 1 {
+		sort.Slice(outs, func(i, j int) bool {
+			a, b := outs[i], outs[j]
+			if ac.depths[a] != ac.depths[b] {
+				return ac.depths[a] > ac.depths[b]
+			}
+			return ac.outputs[a] < ac.outputs[b]
+		})
+	}
+	sc.outs = outs
+	for _, s := range outs {
+		if !sc.hit(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// hit 报告状态 s 的模式串在当前位置结束的命中，需要检查单词边界时先缓存起来
+func (sc *scanner[V]) hit(s int32) bool {
+	ac := sc.ac
+	index := ac.outputs[s]
+	start := sc.origins[(sc.units-int(ac.depths[s]))%len(sc.origins)]
+	h := Hit[V]{
+		Index:     int(index),
+		Start:     start.pos,
+		End:       sc.pos,
+		ByteStart: start.bytePos,
+		ByteEnd:   sc.bytePos,
+		Value:     ac.values[index],
+		Values:    ac.valuesOf(int(index)),
+	}
+	if ac.bounded {
+		if ac.bounds[index]&boundStart == 0 || !isWordRune(start.prev) {
+			sc.deferred = append(sc.deferred, h)
+		}
+		return true
+	}
+	return sc.emit(h)
+}
+
+// addState 把非根状态 s 加入 states，已经存在时不重复加入
+func addState(states []int32, s int32) []int32 {
+	if s == 0 || containsState(states, s) {
+		return states
+	}
+	return append(states, s)
+}
+
+func containsState(states []int32, s int32) bool {
+	for _, t := range states {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (sc *scanner[V]) emit(h Hit[V]) bool {
+	if sc.lm != nil {
+		sc.lm.add(h)
+		return true
+	}
+	return sc.fn(h)
+}
+
+// release 下一个原始字符已经到达，输出等待中的命中，
+// wordNext 为下一个字符是否为单词字符，输入结束时为 false
+func (sc *scanner[V]) release(wordNext bool) bool {
+	for _, h := range sc.deferred {
+		if wordNext && sc.ac.bounds[h.Index]&boundEnd != 0 {
+			continue
+		}
+		if !sc.emit(h) {
+			sc.deferred = sc.deferred[:0]
+			return false
+		}
+	}
+	sc.deferred = sc.deferred[:0]
+	return true
+}
+
+// minStart 以后的命中（包括等待中的命中）最小可能的起始位置
+func (sc *scanner[V]) minStart() int {
+	ms := sc.pos
+	if u := sc.units - len(sc.origins) + 1; u <= 0 {
+		ms = 0
+	} else if u < sc.units {
+		ms = sc.origins[u%len(sc.origins)].pos
+	}
+	for _, h := range sc.deferred {
+		if h.Start < ms {
+			ms = h.Start
+		}
+	}
+	return ms
+}
+
+// finish 输入结束，输出缓存的命中
+func (sc *scanner[V]) finish() bool {
+	if !sc.release(false) {
+		return false
+	}
+	if sc.lm != nil {
+		return sc.lm.finish(sc.fn)
+	}
+	return true
+}
+
+func (sc *scanner[V]) runes(seq []rune) bool {
+	for _, r := range seq {
+		if !sc.feed(r, runeSize(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (sc *scanner[V]) string(s string) bool {
+	return eachString(s, sc.feed)
+}
+
+func (sc *scanner[V]) bytes(b []byte) bool {
+	return eachBytes(b, sc.feed)
+}
+
+// eachString 依次以 s 中的字符和它占的字节数调用 f，f 返回 false 时停止并返回 false
+func eachString(s string, f func(r rune, size int) bool) bool {
+	for i, r := range s {
+		size := utf8.RuneLen(r)
+		if r == utf8.RuneError {
+			_, size = utf8.DecodeRuneInString(s[i:])
+		}
+		if !f(r, size) {
+			return false
+		}
+	}
+	return true
+}
+
+// eachBytes 同 eachString，b 为 UTF-8 编码的文本
+func eachBytes(b []byte, f func(r rune, size int) bool) bool {
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		if !f(r, size) {
+			return false
+		}
+		b = b[size:]
+	}
+	return true
+}
+
+// runeSize 字符 r 的 UTF-8 编码长度
+func runeSize(r rune) int {
+	if size := utf8.RuneLen(r); size > 0 {
+		return size
+	}
+	return utf8.RuneLen(utf8.RuneError)
+}
+
+// input 把一段输入依次喂给 scanner
+type input[V any] func(sc *scanner[V]) bool
+
+func runesInput[V any](seq []rune) input[V] {
+	return func(sc *scanner[V]) bool {
+		return sc.runes(seq)
+	}
+}
+
+func stringInput[V any](s string) input[V] {
+	return func(sc *scanner[V]) bool {
+		return sc.string(s)
+	}
+}
+
+func bytesInput[V any](b []byte) input[V] {
+	return func(sc *scanner[V]) bool {
+		return sc.bytes(b)
+	}
+}
+
+// scan 按结束位置顺序报告 in 的所有命中，fn 返回 false 时停止并返回 false
+func (ac *AutomationOf[V]) scan(in input[V], fn func(Hit[V]) bool) bool {
+	sc := ac.newScanner(MatchStandard, fn)
+	return in(sc) && sc.finish()
+}
+
+// matchFunc 按照 SetMatchKind 设置的方式匹配
+func (ac *AutomationOf[V]) matchFunc(in input[V], fn func(Hit[V]) bool) {
+	ac.matchKind(in, ac.kind, fn)
+}
+
+func (ac *AutomationOf[V]) matchKind(in input[V], kind MatchKind, fn func(Hit[V]) bool) {
+	sc := ac.newScanner(kind, fn)
+	if in(sc) {
+		sc.finish()
+	}
+}
+
+// leftmost 从按结束位置依次到达的重叠命中中选出不重叠的命中，matchUnion 时把重叠的命中合并
+// 以后的命中起始位置都不会小于 scanner.minStart，起始位置更小的候选就可以确定下来，
+// 因此只需要缓存最近 maxDepth 个字符内的命中
+type leftmost[V any] struct {
+	kind    MatchKind
+	cursor  int // 上一个选中的命中的结束位置
+	pending []Hit[V]
+}
+
+func (lm *leftmost[V]) add(h Hit[V]) {
+	if lm.kind == matchUnion {
+		lm.merge(h)
+		return
+	}
+	if h.Start >= lm.cursor {
+		lm.pending = append(lm.pending, h)
+	}
+}
+
+// better 同一起始位置的两个命中，a 是否优先于 b
+func (lm *leftmost[V]) better(a, b Hit[V]) bool {
+	if lm.kind == MatchLeftmostLongest {
+		return a.End > b.End
+	}
+	return a.Index < b.Index
+}
+
+// flush 以后的命中起始位置都不小于 minStart 时，输出已经确定的命中
+func (lm *leftmost[V]) flush(minStart int, fn func(Hit[V]) bool) bool {
+	if lm.kind == matchUnion {
+		return lm.flushUnion(minStart, fn)
+	}
+	for len(lm.pending) > 0 {
+		best := 0
+		for i, h := range lm.pending[1:] {
+			b := lm.pending[best]
+			if h.Start < b.Start || h.Start == b.Start && lm.better(h, b) {
+				best = i + 1
+			}
+		}
+		h := lm.pending[best]
+		if h.Start >= minStart {
+			return true
+		}
+		if !fn(h) {
+			lm.pending = lm.pending[:0]
+			return false
+		}
+		lm.cursor = h.End
+		kept := lm.pending[:0]
+		for _, p := range lm.pending {
+			if p.Start >= lm.cursor {
+				kept = append(kept, p)
+			}
+		}
+		lm.pending = kept
+	}
+	return true
+}
+
+// merge matchUnion 时把 h 与 pending 中和它重叠的区间合并，pending 按起始位置排列、互不重叠
+func (lm *leftmost[V]) merge(h Hit[V]) {
+	i := 0
+	for i < len(lm.pending) && lm.pending[i].End <= h.Start {
+		i++
+	}
+	j := i
+	for ; j < len(lm.pending) && lm.pending[j].Start < h.End; j++ {
+		if p := lm.pending[j]; p.Start < h.Start {
+			h.Start, h.ByteStart = p.Start, p.ByteStart
+		}
+		if p := lm.pending[j]; p.End > h.End {
+			h.End, h.ByteEnd = p.End, p.ByteEnd
+		}
+	}
+	if i == j {
+		lm.pending = append(lm.pending, h)
+		copy(lm.pending[i+1:], lm.pending[i:])
+		lm.pending[i] = h
+		return
+	}
+	lm.pending[i] = h
+	lm.pending = append(lm.pending[:i+1], lm.pending[j:]...)
+}
+
+// flushUnion 以后的命中起始位置都不小于 minStart 时，结束位置不超过 minStart 的区间不会再变化
+func (lm *leftmost[V]) flushUnion(minStart int, fn func(Hit[V]) bool) bool {
+	n := 0
+	for n < len(lm.pending) && lm.pending[n].End <= minStart {
+		if !fn(lm.pending[n]) {
+			lm.pending = lm.pending[:0]
+			return false
+		}
+		n++
+	}
+	lm.pending = append(lm.pending[:0], lm.pending[n:]...)
+	return true
+}
+
+// finish 输入结束，输出剩下的命中
+func (lm *leftmost[V]) finish(fn func(Hit[V]) bool) bool {
+	return lm.flush(endOfInput, fn)
+}
@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalizer 匹配前对字符做归一化，插入的模式串和待匹配的文本都会经过同样的处理
+type Normalizer interface {
+	// Normalize 把 r 归一化之后的字符追加到 buf 并返回，不追加任何字符表示丢弃 r
+	Normalize(r rune, buf []rune) []rune
+}
+
+// NormalizerFunc 函数形式的 Normalizer
+type NormalizerFunc func(r rune, buf []rune) []rune
+
+func (f NormalizerFunc) Normalize(r rune, buf []rune) []rune {
+	return f(r, buf)
+}
+
+// DefaultNormalizer 基于 NFKD 的逐字符归一化：
+// 全角、圈字母、数学字母、连字等兼容字符分解为普通字符，韩文音节分解为字母，大小写折叠，
+// 去掉零宽字符、格式控制字符和通用的组合变音符号（é 的重音等）；
+// 预组合字符和“字母+组合字符”两种写法的结果相同，泰文、天城文等文字自己的组合字符保留
+var DefaultNormalizer Normalizer = NormalizerFunc(foldRune)
+
+// SetNormalizer 设置归一化处理，多个 Normalizer 依次执行，必须在 Insert 之前设置
+// Insert 之后调用时设置不生效，之后的 Insert 和 Compile 返回 ErrInserted
+// 命中的位置仍然是原始文本中的位置
+func (ac *AutomationOf[V]) SetNormalizer(ns ...Normalizer) *AutomationOf[V] {
+	if len(ac.datas) > 0 {
+		ac.err = ErrInserted
+		return ac
+	}
+	ac.norms = ns
+	return ac
+}
+
+// normalizeKey 归一化模式串，并去掉 SetSkip 设置的噪声字符
+func (ac *AutomationOf[V]) normalizeKey(data []rune) []rune {
+	if len(ac.norms) == 0 && ac.skip == nil {
+		return data
+	}
+	var key, cur, tmp []rune
+	for _, r := range data {
+		cur, tmp = normalizeRune(ac.norms, r, cur, tmp)
+		for _, c := range cur {
+			if ac.skip == nil || !ac.skip(c) {
+				key = append(key, c)
+			}
+		}
+	}
+	return key
+}
+
+// normalizeRune 依次执行 ns，结果保存在返回的第一个切片中，第二个切片为可以复用的临时空间
+func normalizeRune(ns []Normalizer, r rune, cur, tmp []rune) ([]rune, []rune) {
+	cur = append(cur[:0], r)
+	for _, n := range ns {
+		tmp = tmp[:0]
+		for _, c := range cur {
+			tmp = n.Normalize(c, tmp)
+		}
+		cur, tmp = tmp, cur
+	}
+	return cur, tmp
+}
+
+// 韩文音节按 Unicode 规定的算法分解，不在 NFKD 的分解表中
+const (
+	hangulBase  = 0xAC00
+	hangulCount = 11172
+	jamoLBase   = 0x1100
+	jamoVBase   = 0x1161
+	jamoTBase   = 0x11A7
+	jamoVCount  = 21
+	jamoTCount  = 28
+)
+
+func foldRune(r rune, buf []rune) []rune {
+	if r < utf8.RuneSelf {
+		return foldDecomposed(r, buf)
+	}
+	if r >= hangulBase && r < hangulBase+hangulCount {
+		n := r - hangulBase
+		buf = append(buf, jamoLBase+n/(jamoVCount*jamoTCount), jamoVBase+n%(jamoVCount*jamoTCount)/jamoTCount)
+		if t := n % jamoTCount; t > 0 {
+			buf = append(buf, jamoTBase+t)
+		}
+		return buf
+	}
+	var b [utf8.UTFMax]byte
+	d := norm.NFKD.Properties(b[:utf8.EncodeRune(b[:], r)]).Decomposition()
+	if d == nil {
+		return foldDecomposed(r, buf)
+	}
+	for len(d) > 0 {
+		c, size := utf8.DecodeRune(d)
+		buf = foldDecomposed(c, buf)
+		d = d[size:]
+	}
+	return buf
+}
+
+// foldDecomposed 处理已经完全分解的字符
+func foldDecomposed(r rune, buf []rune) []rune {
+	if isInvisible(r) {
+		return buf
+	}
+	return append(buf, unicode.ToLower(r))
+}
+
+// isInvisible 零宽字符、格式控制字符、通用的组合变音符号等不可见字符
+func isInvisible(r rune) bool {
+	switch r {
+	case '\t', '\n', '\r':
+		return false
+	case 0x115F, 0x1160, 0x3164, 0xFFA0: // 韩文填充字符
+		return true
+	}
+	return isDiacritic(r) || unicode.In(r, unicode.Me, unicode.Cf, unicode.Cc)
+}
+
+// isDiacritic 不属于某种文字的通用组合变音符号，常用于拉丁、希腊、西里尔字母，
+// 也常被用来在文字上叠加干扰符号
+func isDiacritic(r rune) bool {
+	return r >= 0x0300 && r <= 0x036F || r >= 0x1AB0 && r <= 0x1AFF ||
+		r >= 0x1DC0 && r <= 0x1DFF || r >= 0x20D0 && r <= 0x20FF || r >= 0xFE20 && r <= 0xFE2F
+}
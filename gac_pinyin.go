@@ -0,0 +1,746 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// PinyinDict 汉字到不带声调的小写拼音的映射，ü 写作 v，第一个读音为常用读音
+type PinyinDict map[rune][]string
+
+var (
+	pinyinOnce sync.Once
+	pinyinDict PinyinDict
+)
+
+// DefaultPinyinDict 内置的常用汉字拼音表，每个字只有常用读音，返回的是副本，可以修改
+// 需要完整的字表和多音字时可以用 LoadPinyinDict 加载 pinyin-data 等离线字典
+func DefaultPinyinDict() PinyinDict {
+	pinyinOnce.Do(func() {
+		pinyinDict = PinyinDict{}
+		for _, line := range strings.Split(pinyinTable, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				continue
+			}
+			for _, r := range fields[1] {
+				pinyinDict[r] = []string{fields[0]}
+			}
+		}
+	})
+	d := make(PinyinDict, len(pinyinDict))
+	for k, v := range pinyinDict {
+		d[k] = v
+	}
+	return d
+}
+
+// LoadPinyinDict 读取拼音字典，每行为汉字和逗号或空白分隔的读音，# 之后为注释
+// 汉字可以写作 "中" 或 "U+4E2D"，后面可以跟冒号，例如 pinyin-data 的 "U+4E2D: zhōng,zhòng  # 中"；
+// 读音中的声调符号和数字声调会被去掉，重复的读音只保留一个
+func LoadPinyinDict(r io.Reader) (PinyinDict, error) {
+	d := PinyinDict{}
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ':' || unicode.IsSpace(r)
+		})
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("pinyin: line %d: want character and readings", lineNo)
+		}
+		han, ok := parseHan(fields[0])
+		if !ok {
+			return nil, fmt.Errorf("pinyin: line %d: bad character %q", lineNo, fields[0])
+		}
+		for _, f := range fields[1:] {
+			py := stripTone(f)
+			if py == "" {
+				return nil, fmt.Errorf("pinyin: line %d: bad reading %q", lineNo, f)
+			}
+			if !containsString(d[han], py) {
+				d[han] = append(d[han], py)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func parseHan(s string) (rune, bool) {
+	if strings.HasPrefix(s, "U+") {
+		v, err := strconv.ParseUint(s[2:], 16, 32)
+		return rune(v), err == nil
+	}
+	rs := []rune(s)
+	return rs[0], len(rs) == 1
+}
+
+// toneless 带声调的元音对应的字母
+var toneless = map[rune]rune{
+	'ā': 'a', 'á': 'a', 'ǎ': 'a', 'à': 'a',
+	'ē': 'e', 'é': 'e', 'ě': 'e', 'è': 'e', 'ê': 'e',
+	'ī': 'i', 'í': 'i', 'ǐ': 'i', 'ì': 'i',
+	'ō': 'o', 'ó': 'o', 'ǒ': 'o', 'ò': 'o',
+	'ū': 'u', 'ú': 'u', 'ǔ': 'u', 'ù': 'u',
+	'ü': 'v', 'ǖ': 'v', 'ǘ': 'v', 'ǚ': 'v', 'ǜ': 'v',
+	'ń': 'n', 'ň': 'n', 'ǹ': 'n', 'ḿ': 'm',
+}
+
+// stripTone 去掉声调，不是拼音时返回空串
+func stripTone(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if to, ok := toneless[r]; ok {
+			r = to
+		}
+		switch {
+		case r >= 'a' && r <= 'z':
+			b.WriteRune(r)
+		case r >= '0' && r <= '5':
+		default:
+			return ""
+		}
+	}
+	return b.String()
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Pinyin r 的常用读音
+func (d PinyinDict) Pinyin(r rune) (string, bool) {
+	if pys := d[r]; len(pys) > 0 {
+		return pys[0], true
+	}
+	return "", false
+}
+
+// HomophoneNormalizer 把常用读音相同的汉字归一为同一个字（读音相同的字中编码最小的），
+// 例如 "八"、"巴"、"吧" 归一为同一个字，不在字典中的字符不变
+func (d PinyinDict) HomophoneNormalizer() Normalizer {
+	reps := map[string]rune{}
+	for r, pys := range d {
+		if len(pys) == 0 {
+			continue
+		}
+		if rep, ok := reps[pys[0]]; !ok || r < rep {
+			reps[pys[0]] = r
+		}
+	}
+	m := CharMap{}
+	for r, pys := range d {
+		if len(pys) > 0 {
+			m[r] = reps[pys[0]]
+		}
+	}
+	return m
+}
+
+// PinyinVariant 拼音自动机为每个模式串额外建立索引的方式，可以组合
+type PinyinVariant int
+
+const (
+	PinyinFull      PinyinVariant = 1 << iota // 全拼，"八婆" 索引为 "bapo"
+	PinyinInitials                            // 首字母，"八婆" 索引为 "bp"
+	PinyinHomophone                           // 同音字，"巴婆" 也能命中 "八婆"
+)
+
+// maxPinyinVariants 多音字组合出的全拼或首字母变体的最大个数
+const maxPinyinVariants = 16
+
+// PinyinAutomationOf 按拼音变体索引模式串的自动机
+// 每个模式串的全拼、首字母变体作为额外的模式串插入，value 与原模式串相同；
+// 同音字通过 HomophoneNormalizer 在插入和匹配时归一，不会增加模式串
+// 命中的 Index 是原模式串的下标，GetMatched 返回原模式串
+type PinyinAutomationOf[V any] struct {
+	ac       *AutomationOf[V]
+	dict     PinyinDict
+	variants PinyinVariant
+	datas    [][]rune
+	values   []V
+	sources  []int // ac 中第 i 个模式串对应的原模式串下标
+}
+
+// PinyinAutomation value 为 interface{} 的拼音自动机
+type PinyinAutomation = PinyinAutomationOf[interface{}]
+
+func GenPinyinAutomation(dict PinyinDict, variants PinyinVariant) *PinyinAutomation {
+	return GenPinyinAutomationOf[interface{}](dict, variants)
+}
+
+// GenPinyinAutomationOf 创建拼音自动机，dict 为 nil 时使用 DefaultPinyinDict
+// 默认使用 DefaultNormalizer，并跳过空白和标点（每处最多 2 个），"ba po"、"b-p" 都能命中，
+// 可以在 Insert 之前通过 Automation 修改
+func GenPinyinAutomationOf[V any](dict PinyinDict, variants PinyinVariant) *PinyinAutomationOf[V] {
+	if dict == nil {
+		dict = DefaultPinyinDict()
+	}
+	ac := GenAutomationOf[V]()
+	if variants&PinyinHomophone != 0 {
+		ac.SetNormalizer(DefaultNormalizer, dict.HomophoneNormalizer())
+	} else {
+		ac.SetNormalizer(DefaultNormalizer)
+	}
+	ac.SetSkip(SkipAny(SkipSpace, SkipPunct), 2)
+	return &PinyinAutomationOf[V]{ac: ac, dict: dict, variants: variants}
+}
+
+// Automation 底层的自动机，可以用来设置匹配方式、后端等，命中的 Index 是变体的下标
+func (pa *PinyinAutomationOf[V]) Automation() *AutomationOf[V] {
+	return pa.ac
+}
+
+// Insert 插入模式串及其拼音变体，已经 Compile 时返回 ErrCompiled
+// 拼音变体要求单词边界，"ba po"、"bapo" 命中 "八婆"，"kebapolitan"、"bpm" 不会
+func (pa *PinyinAutomationOf[V]) Insert(data []rune, value V) error {
+	if pa.ac.compiled {
+		return ErrCompiled
+	}
+	index := len(pa.datas)
+	variants := [][]rune{data}
+	if pa.variants&PinyinFull != 0 {
+		variants = append(variants, pa.expand(data, false)...)
+	}
+	if pa.variants&PinyinInitials != 0 {
+		for _, v := range pa.expand(data, true) {
+			// 单个字母的首字母变体几乎处处命中
+			if len(v) > 1 {
+				variants = append(variants, v)
+			}
+		}
+	}
+	seen := map[string]bool{}
+	for k, v := range variants {
+		if seen[string(v)] {
+			continue
+		}
+		seen[string(v)] = true
+		// variants[0] 是原模式串，按 SetWordBoundary 的设置插入
+		i, err := pa.ac.insert(v, value, k > 0 || pa.ac.word)
+		switch {
+		case err != nil:
+			return err
+		case i == len(pa.sources):
+			pa.sources = append(pa.sources, index)
+		case i >= 0 && pa.ac.dup == DuplicateReplace:
+			// 与其他模式串的变体重复，按照 DuplicatePolicy 归属于后插入的模式串
+			pa.sources[i] = index
+		}
+	}
+	pa.datas = append(pa.datas, data)
+	pa.values = append(pa.values, value)
+	return nil
+}
+
+// MustInsert 同 Insert，出错时 panic
+func (pa *PinyinAutomationOf[V]) MustInsert(data []rune, value V) {
+	must(pa.Insert(data, value))
+}
+
+// expand 把 data 中的汉字替换为拼音或拼音首字母，多音字组合出多个变体，
+// 没有可替换的汉字时返回 nil
+func (pa *PinyinAutomationOf[V]) expand(data []rune, initials bool) [][]rune {
+	variants := [][]rune{nil}
+	replaced := false
+	for _, r := range data {
+		pys := pa.dict[r]
+		if len(pys) == 0 {
+			for i := range variants {
+				variants[i] = append(variants[i], r)
+			}
+			continue
+		}
+		replaced = true
+		var next [][]rune
+		var seen []string
+		for _, py := range pys {
+			if initials {
+				py = py[:1]
+			}
+			if containsString(seen, py) {
+				continue
+			}
+			seen = append(seen, py)
+			for _, v := range variants {
+				if len(next) == maxPinyinVariants {
+					break
+				}
+				next = append(next, append(append([]rune{}, v...), []rune(py)...))
+			}
+		}
+		variants = next
+	}
+	if !replaced {
+		return nil
+	}
+	return variants
+}
+
+// Compile 编译底层的自动机，错误同 AutomationOf.Compile
+func (pa *PinyinAutomationOf[V]) Compile() error {
+	return pa.ac.Compile()
+}
+
+// MustCompile 同 Compile，出错时 panic
+func (pa *PinyinAutomationOf[V]) MustCompile() {
+	must(pa.Compile())
+}
+
+// MatchFunc 按照底层自动机的匹配方式匹配，每次命中调用 fn，fn 返回 false 时停止
+// 没有 Compile 时返回 ErrNotCompiled
+func (pa *PinyinAutomationOf[V]) MatchFunc(seq []rune, fn func(Hit[V]) bool) error {
+	return pa.ac.MatchFunc(seq, pa.mapHit(fn))
+}
+
+// MustMatchFunc 同 MatchFunc，出错时 panic
+func (pa *PinyinAutomationOf[V]) MustMatchFunc(seq []rune, fn func(Hit[V]) bool) {
+	must(pa.MatchFunc(seq, fn))
+}
+
+// MatchStringFunc 同 MatchFunc，s 为 UTF-8 编码的文本
+func (pa *PinyinAutomationOf[V]) MatchStringFunc(s string, fn func(Hit[V]) bool) error {
+	return pa.ac.MatchStringFunc(s, pa.mapHit(fn))
+}
+
+// MustMatchStringFunc 同 MatchStringFunc，出错时 panic
+func (pa *PinyinAutomationOf[V]) MustMatchStringFunc(s string, fn func(Hit[V]) bool) {
+	must(pa.MatchStringFunc(s, fn))
+}
+
+func (pa *PinyinAutomationOf[V]) mapHit(fn func(Hit[V]) bool) func(Hit[V]) bool {
+	return func(h Hit[V]) bool {
+		h.Index = pa.sources[h.Index]
+		return fn(h)
+	}
+}
+
+// GetMatched 原模式串及其 value，下标越界时返回 ErrBadIndex
+func (pa *PinyinAutomationOf[V]) GetMatched(index int) ([]rune, V, error) {
+	if index < 0 || index >= len(pa.datas) {
+		var zero V
+		return nil, zero, ErrBadIndex
+	}
+	return pa.datas[index], pa.values[index], nil
+}
+
+// MustGetMatched 同 GetMatched，出错时 panic
+func (pa *PinyinAutomationOf[V]) MustGetMatched(index int) ([]rune, V) {
+	data, value, err := pa.GetMatched(index)
+	must(err)
+	return data, value
+}
+
+// pinyinTable 每行为拼音和常用读音为该拼音的汉字
+const pinyinTable = `
+a 阿啊
+ai 爱哎唉埃挨哀矮艾碍癌
+an 安按暗岸案俺鞍氨
+ang 昂肮
+ao 奥傲熬凹澳袄
+ba 八巴吧爸拔把坝霸罢疤笆芭扒捌叭
+bai 白百摆败拜柏佰
+ban 办半班般板版搬伴扮拌瓣斑颁绊
+bang 帮棒膀榜邦绑傍磅
+bao 包保报抱宝爆薄饱胞堡豹暴剥雹褒
+bei 北被背杯倍悲备贝辈碑卑
+ben 本奔笨苯
+beng 崩绷蹦泵
+bi 比笔必闭逼鼻币避壁彼毕碧蔽弊臂毙鄙屄
+bian 边变便遍编辩扁鞭贬辨
+biao 表标彪膘婊
+bie 别憋鳖
+bin 宾滨彬斌濒
+bing 并病兵冰饼丙柄秉
+bo 波播博伯玻拨脖驳泊勃搏
+bu 不步部布补捕卜哺
+ca 擦
+cai 才菜彩采材财猜裁踩
+can 参餐残惨蚕灿
+cang 藏仓苍舱
+cao 草操曹槽糙肏
+ce 册侧测厕策
+ceng 层曾
+cha 茶查差插叉察岔
+chai 柴拆
+chan 产缠蝉馋铲颤
+chang 长常场厂唱尝肠偿畅倡
+chao 朝超吵抄潮巢炒钞
+che 车彻撤扯
+chen 陈沉晨尘臣衬趁
+cheng 成城程称承乘诚呈惩撑橙
+chi 吃池迟尺持赤齿耻斥翅驰
+chong 冲虫充崇宠
+chou 抽仇愁丑筹酬绸臭
+chu 出处初除楚础储触厨锄雏
+chuan 穿川传船串喘
+chuang 窗床创闯疮
+chui 吹垂锤炊
+chun 春纯唇蠢醇
+chuo 戳绰
+ci 次此词辞刺瓷慈磁雌赐
+cong 从聪葱丛匆
+cou 凑
+cu 粗促醋簇
+cuan 窜篡
+cui 催脆翠崔摧
+cun 村存寸
+cuo 错措搓挫
+da 大打达答搭
+dai 代带待袋戴呆贷逮
+dan 单但蛋胆担淡丹旦诞
+dang 当党挡档荡
+dao 到道倒刀导岛盗稻悼蹈
+de 的得德
+deng 等灯登瞪凳邓
+di 地第低底敌弟帝滴抵递堤笛
+dian 点电店典垫殿颠淀
+diao 掉调吊钓雕屌
+die 跌爹叠蝶
+ding 定顶丁订钉盯
+diu 丢
+dong 动东懂冬洞冻栋
+dou 都斗豆抖逗陡
+du 读度独毒堵肚渡杜督赌镀
+duan 段短断端锻
+dui 对队堆兑
+dun 吨顿蹲盾钝
+duo 多夺朵躲舵堕
+e 饿额鹅恶俄讹
+en 恩
+er 二而儿耳尔饵
+fa 发法罚乏伐阀
+fan 反饭犯番翻凡烦范繁帆贩
+fang 方放房防访纺芳仿
+fei 飞非费肥废肺匪菲
+fen 分份粉奋愤纷坟粪
+feng 风封丰峰疯锋蜂逢缝奉凤讽
+fo 佛
+fou 否
+fu 父夫服福副富付复府妇负扶浮符腐伏抚辅幅赋
+ga 嘎
+gai 该改盖概钙
+gan 干感敢赶杆肝甘
+gang 刚钢岗港纲缸
+gao 高告搞稿膏糕
+ge 个哥歌格各革割隔戈鸽
+gei 给
+gen 根跟
+geng 更耕
+gong 工公共功攻供宫贡恭巩
+gou 够狗沟构购勾
+gu 古故顾骨谷鼓股固姑孤
+gua 挂瓜刮寡
+guai 怪乖拐
+guan 关管官观馆惯冠贯灌罐
+guang 光广逛
+gui 贵鬼规归桂柜跪轨龟
+gun 滚棍
+guo 国过果锅郭裹
+ha 哈
+hai 还海害孩亥骇
+han 汉喊含寒汗韩旱憾
+hang 航杭
+hao 好号毫豪耗浩
+he 和合河喝何盒贺核荷鹤
+hei 黑嘿
+hen 很恨狠痕
+heng 横恒衡哼
+hong 红洪宏虹轰哄
+hou 后候厚猴吼
+hu 湖虎户护呼乎互胡壶糊忽狐
+hua 话花化画华划滑哗
+huai 坏怀淮槐
+huan 换欢环缓唤幻患
+huang 黄皇慌荒晃谎煌
+hui 会回灰挥辉毁汇绘慧惠悔
+hun 婚混魂昏浑
+huo 活火或货获伙祸惑
+ji 几机基及记级急极即集计技济积击鸡激既纪际季寄挤吉忌迹妓
+jia 家加假价架甲佳夹嫁驾稼
+jian 见间件建简坚健检减渐剑尖肩监箭践键舰鉴贱奸
+jiang 将讲江奖降酱姜蒋
+jiao 叫教交角较脚胶焦骄浇郊娇
+jie 接节街结解姐界借阶介届洁截戒揭杰
+jin 进近今金紧仅尽劲津禁锦谨晋
+jing 经精京静境景竞镜敬净警径井惊睛晶
+jiong 窘
+jiu 就九久酒旧救究纠揪
+ju 局举具据剧居句巨拒聚菊鞠俱惧
+juan 卷捐娟倦绢
+jue 觉决绝掘诀
+jun 军均君菌俊峻
+ka 卡咖
+kai 开凯慨楷
+kan 看砍刊堪勘
+kang 康抗扛炕
+kao 考靠烤
+ke 可课科克客刻渴颗壳柯棵
+ken 肯恳啃垦
+keng 坑
+kong 空控孔恐
+kou 口扣寇
+ku 苦哭库裤酷枯窟
+kua 夸跨垮
+kuai 快块筷
+kuan 宽款
+kuang 况矿狂框旷筐
+kui 亏愧溃葵魁窥
+kun 困昆捆
+kuo 扩括阔廓
+la 拉啦辣蜡腊
+lai 来赖莱
+lan 蓝兰烂栏懒篮览滥
+lang 浪狼郎朗廊
+lao 老劳牢捞涝
+le 了乐勒
+lei 类累雷泪垒
+leng 冷楞
+li 里理力利立李历例离丽礼粒黎厉励璃梨
+lia 俩
+lian 连脸练联恋炼莲廉怜链帘
+liang 两量亮良凉梁粮谅辆
+liao 料疗聊辽僚
+lie 列烈裂猎劣
+lin 林临邻淋磷鳞吝
+ling 另领令零灵龄铃岭陵凌
+liu 六流留刘柳溜
+long 龙隆笼聋拢垄
+lou 楼漏搂陋
+lu 路陆录露鲁炉卢芦鹿碌
+lv 绿律旅率虑驴吕铝
+luan 乱卵
+lun 论轮伦
+luo 落罗络洛逻骆锣
+ma 妈马吗骂麻码
+mai 买卖麦埋迈脉
+man 满慢漫蛮馒瞒
+mang 忙盲茫芒
+mao 毛猫帽冒貌茂贸矛
+me 么
+mei 没美每妹梅媒煤眉霉
+men 们门闷
+meng 梦猛蒙盟孟
+mi 米密迷秘蜜谜眯
+mian 面免棉眠绵
+miao 秒苗描庙妙
+mie 灭
+min 民敏闽
+ming 名明命鸣铭
+mo 模磨末莫魔摸默墨膜陌抹
+mou 某谋
+mu 母木目幕慕墓牧姆
+na 那拿哪纳钠
+nai 奶耐乃奈
+nan 男南难
+nang 囊
+nao 脑闹恼
+ne 呢
+nei 内
+nen 嫩
+neng 能
+ni 你尼泥逆拟腻
+nian 年念黏捻
+niang 娘酿
+niao 鸟尿
+nie 捏聂镍
+nin 您
+ning 宁凝拧
+niu 牛扭纽
+nong 农弄浓
+nu 怒努奴
+nv 女
+nuan 暖
+nue 虐疟
+nuo 诺挪
+o 哦
+ou 欧偶呕
+pa 怕爬帕
+pai 排派拍牌
+pan 盘判盼攀
+pang 旁胖庞
+pao 跑炮泡抛袍
+pei 配陪培赔佩
+pen 喷盆
+peng 朋碰捧蓬棚鹏
+pi 皮批屁脾匹披疲辟
+pian 片篇骗偏
+piao 票飘漂嫖
+pin 品贫拼频
+ping 平评苹凭瓶屏
+po 破婆坡迫泼颇
+pu 普铺朴葡扑谱浦
+qi 起其期气七器奇骑企旗齐妻弃欺启汽
+qia 恰洽掐
+qian 前钱千签欠迁浅潜牵铅谦
+qiang 强墙枪抢腔
+qiao 桥巧瞧敲乔侨
+qie 切且窃
+qin 亲琴勤侵秦禽
+qing 情请清青轻庆晴倾
+qiong 穷琼
+qiu 求球秋丘囚
+qu 去取区趣曲渠驱
+quan 全权劝泉拳
+que 却确缺雀
+qun 群裙
+ran 然燃染
+rang 让嚷
+rao 绕扰饶
+re 热惹
+ren 人认任仁忍刃
+reng 仍扔
+ri 日
+rong 容荣融绒溶
+rou 肉柔揉
+ru 如入乳儒辱
+ruan 软
+rui 瑞锐
+run 润闰
+ruo 若弱
+sa 撒洒萨
+sai 赛塞
+san 三散伞
+sang 丧桑嗓
+sao 扫嫂骚
+se 色涩
+sen 森
+sha 杀沙啥傻纱
+shai 晒筛
+shan 山闪善扇衫删
+shang 上商伤尚赏
+shao 少烧稍勺哨绍
+she 社设射舍蛇涉摄
+shei 谁
+shen 身深神什甚伸审沈肾渗
+sheng 生声省胜升盛绳圣剩
+shi 是时事十使世市实式师石试识始史食失室视示适施诗湿势尸
+shou 手受收首守售瘦兽寿
+shu 书数树术属输熟叔束鼠舒暑述
+shua 刷耍
+shuai 帅摔衰甩
+shuan 拴
+shuang 双爽霜
+shui 水睡税
+shun 顺瞬
+shuo 说硕
+si 四死思司丝私斯寺似撕
+song 送松宋颂诵
+sou 搜艘
+su 苏素速诉俗宿塑肃
+suan 算酸蒜
+sui 岁随虽碎遂隧
+sun 孙损笋
+suo 所锁缩索
+ta 他她它塔踏
+tai 太台态泰抬胎
+tan 谈弹探叹坦摊贪滩
+tang 堂汤糖躺趟唐烫
+tao 套讨逃桃陶淘
+te 特
+teng 疼腾藤
+ti 体提题替踢梯蹄
+tian 天田甜填添
+tiao 条跳挑
+tie 铁贴
+ting 听停庭厅挺亭
+tong 同通统痛童桶铜
+tou 头投透偷
+tu 土图突徒途涂吐兔
+tuan 团
+tui 推退腿
+tun 吞屯
+tuo 脱托拖妥
+wa 挖娃瓦哇袜
+wai 外歪
+wan 万完玩晚碗弯湾顽
+wang 王往忘网望旺亡
+wei 为位未委围卫伟味微维危威尾谓喂
+wen 问文温闻稳吻纹
+weng 翁
+wo 我握卧窝沃
+wu 五无物务午舞误屋武吴雾乌污
+xi 西系洗喜希习细席息戏析吸稀悉溪锡
+xia 下夏吓侠峡虾瞎
+xian 先现线显限县险鲜献闲仙嫌陷
+xiang 想向相象像香乡响项详箱享
+xiao 小笑校效消晓销萧孝
+xie 写些谢鞋协斜歇泄械
+xin 新心信辛欣薪
+xing 行性姓星形型兴醒幸刑
+xiong 兄胸雄熊凶
+xiu 修休秀袖绣锈
+xu 需许续须虚序徐叙绪
+xuan 选宣旋悬玄
+xue 学雪血穴靴
+xun 寻训讯迅询巡
+ya 呀压牙亚鸭押雅崖
+yan 言眼验研严演烟颜沿延盐宴燕艳
+yang 样阳洋养扬仰杨羊氧痒
+yao 要药摇腰咬遥邀耀
+ye 也业夜叶爷野页液
+yi 一以已意义议医衣移易艺亿忆依疑遗仪宜益异役译
+yin 因音银引印饮阴隐淫
+ying 应影英营硬迎赢映鹰樱
+yo 哟
+yong 用永勇拥泳涌庸
+you 有又由友油游右优邮尤幽犹诱
+yu 于与语雨鱼育余预遇玉域宇欲狱愈誉娱渔
+yuan 元员原远院愿园源圆缘援怨
+yue 月越约跃阅岳悦
+yun 云运允孕晕韵
+za 杂砸
+zai 在再载灾栽
+zan 咱暂赞
+zang 脏葬
+zao 早造遭燥澡糟
+ze 则责泽择
+zei 贼
+zen 怎
+zeng 增赠
+zha 炸扎眨诈
+zhai 摘宅窄债
+zhan 站战展占沾粘斩
+zhang 张章掌丈仗帐涨障
+zhao 找照招赵召罩兆
+zhe 这者着折哲遮浙
+zhen 真镇针阵振诊珍震枕
+zheng 正政整证争征挣睁郑
+zhi 之只知直指制纸止支至职值志治质致智织植执枝
+zhong 中种重众终钟忠肿
+zhou 周州洲舟粥骤皱轴
+zhu 主住注助祝猪朱珠竹筑驻逐著烛
+zhua 抓
+zhuan 转专赚砖
+zhuang 装状庄壮撞
+zhui 追坠
+zhun 准
+zhuo 桌捉卓浊
+zi 子自字资紫姿滋
+zong 总宗综踪纵
+zou 走奏
+zu 组族足祖阻租
+zuan 钻
+zui 最嘴罪醉
+zun 尊遵
+zuo 做作坐左座昨
+`
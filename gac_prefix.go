@@ -0,0 +1,179 @@
+package tools
+
+import "sort"
+
+// Entry 一个模式串及其 value
+type Entry[V any] struct {
+	Index  int    // 模式串下标
+	Data   []rune // 插入时的模式串
+	Value  V      // 模式串的 value
+	Values []V    // 模式串的全部 value，只有 DuplicateAccumulate 时可能有多个，不能修改
+}
+
+func (ac *AutomationOf[V]) entry(index int) Entry[V] {
+	return Entry[V]{
+		Index:  index,
+		Data:   ac.datas[index],
+		Value:  ac.values[index],
+		Values: ac.valuesOf(index),
+	}
+}
+
+// LongestPrefix 找出 seq 开头最长的模式串，没有时第二个返回值为 false
+// seq 与匹配时一样经过归一化并跳过噪声字符，返回的位置为 seq 中的位置，也检查单词边界
+// 没有 Compile 时返回 ErrNotCompiled
+func (ac *AutomationOf[V]) LongestPrefix(seq []rune) (Hit[V], bool, error) {
+	var best Hit[V]
+	if !ac.compiled {
+		return best, false, ErrNotCompiled
+	}
+	found := false
+	// 有形近字符表时同一个前缀可能到达多个状态，它们的深度相同
+	states, nexts := []int32{0}, []int32(nil)
+	start, byteStart, bytePos, gap := -1, 0, 0, 0
+	var cur, tmp []rune
+	for i, r := range seq {
+		size := runeSize(r)
+		cur, tmp = normalizeRune(ac.norms, r, cur, tmp)
+		for _, c := range cur {
+			if ac.skip != nil && ac.skip(c) {
+				if start >= 0 {
+					gap++
+				}
+				continue
+			}
+			if ac.maxGap > 0 && gap > ac.maxGap {
+				return best, found, nil
+			}
+			gap = 0
+			if states, nexts = ac.gotoAll(states, c, nexts), states; len(states) == 0 {
+				return best, found, nil
+			}
+			if start < 0 {
+				start, byteStart = i, bytePos
+			}
+			index := int32(-1)
+			for _, s := range states {
+				if x := ac.outputs[s]; x >= 0 && (index < 0 || x < index) && ac.inBounds(int(x), seq, start, i+1) {
+					index = x
+				}
+			}
+			if index >= 0 {
+				best = Hit[V]{
+					Index:     int(index),
+					Start:     start,
+					End:       i + 1,
+					ByteStart: byteStart,
+					ByteEnd:   bytePos + size,
+					Value:     ac.values[index],
+					Values:    ac.valuesOf(int(index)),
+				}
+				found = true
+			}
+		}
+		bytePos += size
+	}
+	return best, found, nil
+}
+
+// MustLongestPrefix 同 LongestPrefix，出错时 panic
+func (ac *AutomationOf[V]) MustLongestPrefix(seq []rune) (Hit[V], bool) {
+	h, ok, err := ac.LongestPrefix(seq)
+	must(err)
+	return h, ok
+}
+
+// gotoAll states 中的状态沿 goto 转移读入 c 或 c 代替的形近字符之后的全部状态，使用 buf 的空间
+func (ac *AutomationOf[V]) gotoAll(states []int32, c rune, buf []int32) []int32 {
+	buf = buf[:0]
+	for _, s := range states {
+		if t := ac.next(s, c); t >= 0 && !containsState(buf, t) {
+			buf = append(buf, t)
+		}
+		for _, a := range ac.alts[c] {
+			if t := ac.next(s, a); t >= 0 && !containsState(buf, t) {
+				buf = append(buf, t)
+			}
+		}
+	}
+	return buf
+}
+
+// inBounds seq[start:end] 是否满足下标为 index 的模式串的单词边界要求
+func (ac *AutomationOf[V]) inBounds(index int, seq []rune, start, end int) bool {
+	if !ac.bounded {
+		return true
+	}
+	b := ac.bounds[index]
+	if b&boundStart != 0 && start > 0 && isWordRune(seq[start-1]) {
+		return false
+	}
+	if b&boundEnd != 0 && end < len(seq) && isWordRune(seq[end]) {
+		return false
+	}
+	return true
+}
+
+// HasPrefix 是否有以 prefix 开头的模式串，prefix 与模式串一样经过归一化，也按形近字符表展开
+// 没有 Compile 时返回 ErrNotCompiled
+func (ac *AutomationOf[V]) HasPrefix(prefix []rune) (bool, error) {
+	if !ac.compiled {
+		return false, ErrNotCompiled
+	}
+	states := ac.prefixStates(prefix)
+	// 除了根状态，每个状态都在某个模式串的路径上
+	return len(states) > 0 && (states[0] > 0 || ac.firsts[1] > ac.firsts[0]), nil
+}
+
+// MustHasPrefix 同 HasPrefix，出错时 panic
+func (ac *AutomationOf[V]) MustHasPrefix(prefix []rune) bool {
+	ok, err := ac.HasPrefix(prefix)
+	must(err)
+	return ok
+}
+
+// PrefixSearch 返回以 prefix 开头的模式串，最多 limit 个，limit <= 0 时不限制
+// 按归一化之后的模式串的字符顺序排列，短的在前，结果与插入顺序和 Backend 无关
+// 与 HasPrefix 一样按形近字符表展开 prefix，没有 Compile 时返回 ErrNotCompiled
+func (ac *AutomationOf[V]) PrefixSearch(prefix []rune, limit int) ([]Entry[V], error) {
+	if !ac.compiled {
+		return nil, ErrNotCompiled
+	}
+	states := ac.prefixStates(prefix)
+	var entries []Entry[V]
+	// 深度相同的状态按路径的字典序编号，子状态按字符排序，逆序压栈即按字典序先序遍历
+	stack := make([]int32, 0, len(states))
+	for i := len(states) - 1; i >= 0; i-- {
+		stack = append(stack, states[i])
+	}
+	for len(stack) > 0 && (limit <= 0 || len(entries) < limit) {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if index := ac.outputs[s]; index >= 0 {
+			entries = append(entries, ac.entry(int(index)))
+		}
+		for t := ac.firsts[s+1] - 1; t >= ac.firsts[s]; t-- {
+			stack = append(stack, t)
+		}
+	}
+	return entries, nil
+}
+
+// MustPrefixSearch 同 PrefixSearch，出错时 panic
+func (ac *AutomationOf[V]) MustPrefixSearch(prefix []rune, limit int) []Entry[V] {
+	entries, err := ac.PrefixSearch(prefix, limit)
+	must(err)
+	return entries
+}
+
+// prefixStates 从根状态沿 goto 转移读入归一化之后的 prefix 能到达的全部状态，按编号排序
+func (ac *AutomationOf[V]) prefixStates(prefix []rune) []int32 {
+	states, nexts := []int32{0}, []int32(nil)
+	for _, r := range ac.normalizeKey(prefix) {
+		if states, nexts = ac.gotoAll(states, r, nexts), states; len(states) == 0 {
+			return nil
+		}
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i] < states[j] })
+	return states
+}
@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"strings"
+)
+
+// ReplaceAll 把命中的内容替换为 repl 的返回值，返回替换后的结果
+// 被替换的命中之间不重叠，MatchStandard 时按 MatchLeftmostLongest 选择命中
+func (ac *AutomationOf[V]) ReplaceAll(seq []rune, repl func(Hit[V]) []rune) ([]rune, error) {
+	out := make([]rune, 0, len(seq))
+	last := 0
+	err := ac.replaceAll(runesInput[V](seq), func(h Hit[V]) {
+		out = append(out, seq[last:h.Start]...)
+		out = append(out, repl(h)...)
+		last = h.End
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(out, seq[last:]...), nil
+}
+
+// MustReplaceAll 同 ReplaceAll，出错时 panic
+func (ac *AutomationOf[V]) MustReplaceAll(seq []rune, repl func(Hit[V]) []rune) []rune {
+	return mustRunes(ac.ReplaceAll(seq, repl))
+}
+
+// ReplaceAllString 同 ReplaceAll，直接处理 UTF-8 字符串
+func (ac *AutomationOf[V]) ReplaceAllString(s string, repl func(Hit[V]) string) (string, error) {
+	out := &strings.Builder{}
+	out.Grow(len(s))
+	last := 0
+	err := ac.replaceAll(stringInput[V](s), func(h Hit[V]) {
+		out.WriteString(s[last:h.ByteStart])
+		out.WriteString(repl(h))
+		last = h.ByteEnd
+	})
+	if err != nil {
+		return "", err
+	}
+	out.WriteString(s[last:])
+	return out.String(), nil
+}
+
+// MustReplaceAllString 同 ReplaceAllString，出错时 panic
+func (ac *AutomationOf[V]) MustReplaceAllString(s string, repl func(Hit[V]) string) string {
+	return mustString(ac.ReplaceAllString(s, repl))
+}
+
+func (ac *AutomationOf[V]) replaceAll(in input[V], fn func(Hit[V])) error {
+	if !ac.compiled {
+		return ErrNotCompiled
+	}
+	kind := ac.kind
+	if kind == MatchStandard {
+		kind = MatchLeftmostLongest
+	}
+	ac.matchKind(in, kind, func(h Hit[V]) bool {
+		fn(h)
+		return true
+	})
+	return nil
+}
+
+// Mask 把所有命中覆盖到的字符都替换为 mask，返回新的切片
+// 不论 MatchKind，重叠、相邻的命中都会被完整覆盖
+func (ac *AutomationOf[V]) Mask(seq []rune, mask rune) ([]rune, error) {
+	out := append([]rune(nil), seq...)
+	err := ac.mask(runesInput[V](seq), func(h Hit[V]) {
+		for i := h.Start; i < h.End; i++ {
+			out[i] = mask
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MustMask 同 Mask，出错时 panic
+func (ac *AutomationOf[V]) MustMask(seq []rune, mask rune) []rune {
+	return mustRunes(ac.Mask(seq, mask))
+}
+
+// MaskString 同 Mask，直接处理 UTF-8 字符串
+func (ac *AutomationOf[V]) MaskString(s string, mask rune) (string, error) {
+	out := &strings.Builder{}
+	out.Grow(len(s))
+	last := 0
+	err := ac.mask(stringInput[V](s), func(h Hit[V]) {
+		out.WriteString(s[last:h.ByteStart])
+		for i := h.Start; i < h.End; i++ {
+			out.WriteRune(mask)
+		}
+		last = h.ByteEnd
+	})
+	if err != nil {
+		return "", err
+	}
+	out.WriteString(s[last:])
+	return out.String(), nil
+}
+
+// MustMaskString 同 MaskString，出错时 panic
+func (ac *AutomationOf[V]) MustMaskString(s string, mask rune) string {
+	return mustString(ac.MaskString(s, mask))
+}
+
+// mask 把所有命中合并为互不重叠、按顺序排列的区间，依次调用 fn
+func (ac *AutomationOf[V]) mask(in input[V], fn func(Hit[V])) error {
+	if !ac.compiled {
+		return ErrNotCompiled
+	}
+	// 命中按结束位置顺序到达，后到的长命中可能从更早的位置开始，合并之后按起始位置输出
+	ac.matchKind(in, matchUnion, func(h Hit[V]) bool {
+		fn(h)
+		return true
+	})
+	return nil
+}
+
+func mustRunes(rs []rune, err error) []rune {
+	must(err)
+	return rs
+}
+
+func mustString(s string, err error) string {
+	must(err)
+	return s
+}
@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"io"
+	"strings"
+)
+
+// Replacer 用AC自动机实现的 strings.Replacer，适合有大量 old/new 对的替换表，可以并发使用
+// 按 MatchLeftmostLongest 选择替换的位置：从左到右，同一位置取最长的 old
+type Replacer struct {
+	ac *AutomationOf[string]
+}
+
+// NewReplacer 同 strings.NewReplacer，oldnew 为依次排列的 old、new 对，个数为奇数时 panic
+// 同一个 old 出现多次时使用第一次的 new，空的 old 被忽略
+func NewReplacer(oldnew ...string) *Replacer {
+	if len(oldnew)%2 == 1 {
+		panic("automation: odd argument count for NewReplacer")
+	}
+	ac := GenAutomationOf[string]().SetDuplicatePolicy(DuplicateKeepFirst)
+	for i := 0; i < len(oldnew); i += 2 {
+		ac.MustInsert([]rune(oldnew[i]), oldnew[i+1])
+	}
+	ac.Compile()
+	return &Replacer{ac: ac}
+}
+
+// NewReplacerFrom 使用已经插入了模式串的自动机创建 Replacer，模式串的 value 为替换后的内容
+// 可以先设置 Normalizer、SetSkip 或单词边界；没有 Compile 时返回 ErrNotCompiled
+func NewReplacerFrom(ac *AutomationOf[string]) (*Replacer, error) {
+	if !ac.compiled {
+		return nil, ErrNotCompiled
+	}
+	return &Replacer{ac: ac}, nil
+}
+
+// Replace 返回 s 替换之后的结果
+func (r *Replacer) Replace(s string) string {
+	out := &strings.Builder{}
+	out.Grow(len(s))
+	_, _ = r.WriteString(out, s)
+	return out.String()
+}
+
+// WriteString 把 s 替换之后的结果写入 w，返回写入的字节数和第一个写入错误，出错时停止
+func (r *Replacer) WriteString(w io.Writer, s string) (n int, err error) {
+	sw, ok := w.(io.StringWriter)
+	if !ok {
+		sw = stringWriter{w}
+	}
+	write := func(str string) bool {
+		if len(str) == 0 {
+			return true
+		}
+		m, e := sw.WriteString(str)
+		n += m
+		err = e
+		return e == nil
+	}
+	last := 0
+	r.ac.matchKind(stringInput[string](s), MatchLeftmostLongest, func(h Hit[string]) bool {
+		if !write(s[last:h.ByteStart]) || !write(h.Value) {
+			return false
+		}
+		last = h.ByteEnd
+		return true
+	})
+	if err == nil {
+		write(s[last:])
+	}
+	return n, err
+}
+
+type stringWriter struct {
+	w io.Writer
+}
+
+func (sw stringWriter) WriteString(s string) (int, error) {
+	return sw.w.Write([]byte(s))
+}
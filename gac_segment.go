@@ -0,0 +1,202 @@
+package tools
+
+import (
+	"unicode"
+)
+
+// SegmentMode 分词方式
+type SegmentMode int
+
+const (
+	SegmentForward       SegmentMode = iota // 正向最大匹配
+	SegmentBackward                         // 逆向最大匹配
+	SegmentBidirectional                    // 双向最大匹配，取词数少的结果，相同时取单字少的，仍相同时取逆向的
+)
+
+// Token 分词结果中的一个词，seq[Start:End] 即词的内容
+type Token[V any] struct {
+	Index     int // 词典中的模式串下标，未登录词为 -1
+	Start     int // 第一个字符的位置
+	End       int // 最后一个字符之后的位置
+	ByteStart int // 第一个字符的字节位置
+	ByteEnd   int // 最后一个字符之后的字节位置
+	Value     V   // 模式串的 value，未登录词为零值
+}
+
+// Segmenter 以自动机中的模式串为词典的分词器，可以并发使用
+// 词典中的词按照匹配时的方式查找，同样经过归一化、跳过噪声字符并检查单词边界；
+// 词典中没有的部分按字符切分，连续的 ASCII 字母和数字作为一个词
+type Segmenter[V any] struct {
+	ac   *AutomationOf[V]
+	mode SegmentMode
+}
+
+// NewSegmenter 创建分词器，没有 Compile 时返回 ErrNotCompiled
+func (ac *AutomationOf[V]) NewSegmenter(mode SegmentMode) (*Segmenter[V], error) {
+	if !ac.compiled {
+		return nil, ErrNotCompiled
+	}
+	return &Segmenter[V]{ac: ac, mode: mode}, nil
+}
+
+// MustNewSegmenter 同 NewSegmenter，出错时 panic
+func (ac *AutomationOf[V]) MustNewSegmenter(mode SegmentMode) *Segmenter[V] {
+	sg, err := ac.NewSegmenter(mode)
+	must(err)
+	return sg
+}
+
+// Segment 把 seq 切分为首尾相接的词，包括空白和标点在内的每个字符都属于某个词
+func (sg *Segmenter[V]) Segment(seq []rune) []Token[V] {
+	offs := make([]int, len(seq)+1)
+	for i, r := range seq {
+		offs[i+1] = offs[i] + runeSize(r)
+	}
+	return sg.segment(seq, offs)
+}
+
+// SegmentString 同 Segment，直接处理 UTF-8 字符串，s[ByteStart:ByteEnd] 即词的内容
+func (sg *Segmenter[V]) SegmentString(s string) []Token[V] {
+	seq := make([]rune, 0, len(s))
+	offs := make([]int, 1, len(s)+1)
+	eachString(s, func(r rune, size int) bool {
+		seq = append(seq, r)
+		offs = append(offs, offs[len(offs)-1]+size)
+		return true
+	})
+	return sg.segment(seq, offs)
+}
+
+// segmentation 一次分词用到的全部命中，offs[i] 为第 i 个字符的字节位置
+type segmentation[V any] struct {
+	ac    *AutomationOf[V]
+	seq   []rune
+	offs  []int
+	hits  []Hit[V]
+	froms []int32 // froms[i] 为从位置 i 开始的最长命中，没有时为 -1
+	tos   []int32 // tos[i] 为在位置 i 结束的最长命中，没有时为 -1
+}
+
+func (sg *Segmenter[V]) segment(seq []rune, offs []int) []Token[V] {
+	sn := &segmentation[V]{
+		ac:    sg.ac,
+		seq:   seq,
+		offs:  offs,
+		froms: make([]int32, len(seq)+1),
+		tos:   make([]int32, len(seq)+1),
+	}
+	for i := range sn.froms {
+		sn.froms[i], sn.tos[i] = -1, -1
+	}
+	sg.ac.scan(runesInput[V](seq), func(h Hit[V]) bool {
+		k := int32(len(sn.hits))
+		sn.hits = append(sn.hits, h)
+		if f := sn.froms[h.Start]; f < 0 || sn.hits[f].End < h.End {
+			sn.froms[h.Start] = k
+		}
+		if t := sn.tos[h.End]; t < 0 || sn.hits[t].Start > h.Start {
+			sn.tos[h.End] = k
+		}
+		return true
+	})
+	switch sg.mode {
+	case SegmentForward:
+		return sn.forward()
+	case SegmentBackward:
+		return sn.backward()
+	}
+	fw, bw := sn.forward(), sn.backward()
+	if len(fw) != len(bw) {
+		if len(fw) < len(bw) {
+			return fw
+		}
+		return bw
+	}
+	if singles(fw) < singles(bw) {
+		return fw
+	}
+	return bw
+}
+
+// forward 正向最大匹配，每次取从当前位置开始的最长的词
+func (sn *segmentation[V]) forward() []Token[V] {
+	var tokens []Token[V]
+	for i := 0; i < len(sn.seq); {
+		if k := sn.froms[i]; k >= 0 {
+			tokens = append(tokens, sn.token(k))
+			i = sn.hits[k].End
+			continue
+		}
+		j := i + 1
+		if isASCIIWord(sn.seq[i]) {
+			for j < len(sn.seq) && isASCIIWord(sn.seq[j]) && sn.froms[j] < 0 {
+				j++
+			}
+		}
+		tokens = append(tokens, sn.unknown(i, j))
+		i = j
+	}
+	return tokens
+}
+
+// backward 逆向最大匹配，每次取在当前位置结束的最长的词
+func (sn *segmentation[V]) backward() []Token[V] {
+	var tokens []Token[V]
+	for j := len(sn.seq); j > 0; {
+		if k := sn.tos[j]; k >= 0 {
+			tokens = append(tokens, sn.token(k))
+			j = sn.hits[k].Start
+			continue
+		}
+		i := j - 1
+		if isASCIIWord(sn.seq[i]) {
+			for i > 0 && isASCIIWord(sn.seq[i-1]) && sn.tos[i] < 0 {
+				i--
+			}
+		}
+		tokens = append(tokens, sn.unknown(i, j))
+		j = i
+	}
+	for a, b := 0, len(tokens)-1; a < b; a, b = a+1, b-1 {
+		tokens[a], tokens[b] = tokens[b], tokens[a]
+	}
+	return tokens
+}
+
+func (sn *segmentation[V]) token(k int32) Token[V] {
+	h := sn.hits[k]
+	return Token[V]{
+		Index:     h.Index,
+		Start:     h.Start,
+		End:       h.End,
+		ByteStart: sn.offs[h.Start],
+		ByteEnd:   sn.offs[h.End],
+		Value:     h.Value,
+	}
+}
+
+func (sn *segmentation[V]) unknown(start, end int) Token[V] {
+	return Token[V]{
+		Index:     -1,
+		Start:     start,
+		End:       end,
+		ByteStart: sn.offs[start],
+		ByteEnd:   sn.offs[end],
+	}
+}
+
+// singles 只有一个字符的词的数量
+func singles[V any](tokens []Token[V]) int {
+	n := 0
+	for _, t := range tokens {
+		if t.End-t.Start == 1 {
+			n++
+		}
+	}
+	return n
+}
+
+// isASCIIWord 是否为 ASCII 字母或数字
+func isASCIIWord(r rune) bool {
+	return r <= unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SkipSet 判断归一化之后的字符是否为噪声字符，噪声字符出现在命中的中间时会被跳过
+type SkipSet func(r rune) bool
+
+var (
+	// SkipSpace 空白字符
+	SkipSpace SkipSet = unicode.IsSpace
+	// SkipPunct 标点和符号，例如 * . - _ ~ 、。
+	SkipPunct SkipSet = func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSymbol(r)
+	}
+	// SkipEmoji 表情、图形符号以及表情的修饰字符
+	SkipEmoji SkipSet = isEmoji
+)
+
+// SkipRunes chars 中的字符
+func SkipRunes(chars string) SkipSet {
+	return func(r rune) bool {
+		return strings.ContainsRune(chars, r)
+	}
+}
+
+// SkipAny 属于任意一个 sets 的字符
+func SkipAny(sets ...SkipSet) SkipSet {
+	return func(r rune) bool {
+		for _, set := range sets {
+			if set(r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// SetSkip 设置噪声字符，必须在 Insert 之前设置，Insert 之后调用时设置不生效，之后的 Insert 和 Compile 返回 ErrInserted
+// 模式串中的噪声字符会被去掉，匹配时噪声字符不读入自动机，因此 "八*婆"、"八 婆" 都能命中 "八婆"；
+// maxGap 为相邻两个字符之间最多跳过的字符数，<= 0 表示不限制
+// 命中的起止位置是原始文本中第一个和最后一个非噪声字符的位置，中间的噪声字符包含在命中内
+func (ac *AutomationOf[V]) SetSkip(skip SkipSet, maxGap int) *AutomationOf[V] {
+	if len(ac.datas) > 0 {
+		ac.err = ErrInserted
+		return ac
+	}
+	ac.skip = skip
+	ac.maxGap = maxGap
+	return ac
+}
+
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // 麻将牌、扑克、国旗、表情、交通和地图符号等
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // 杂项符号、装饰符号
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF: // 箭头、星形等
+		return true
+	case r >= 0xFE00 && r <= 0xFE0F: // 变体选择符
+		return true
+	case r == 0x200D || r == 0x20E3: // 零宽连接符、组合用方框
+		return true
+	}
+	return false
+}
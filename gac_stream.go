@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// Matcher 流式匹配器，文本可以分多次写入，跨越两次写入的模式串也能命中
+// 命中位置是从第一次写入（或 Reset）开始计算的绝对字符位置和字节位置，匹配方式同 SetMatchKind
+type Matcher[V any] struct {
+	sc      *scanner[V]
+	partial []byte // 上次 Write 末尾不完整的 UTF-8 字节
+	stopped bool
+}
+
+// NewMatcher 创建流式匹配器，每次命中调用 fn，fn 返回 false 时停止匹配
+// 没有 Compile 时返回 ErrNotCompiled
+func (ac *AutomationOf[V]) NewMatcher(fn func(Hit[V]) bool) (*Matcher[V], error) {
+	if !ac.compiled {
+		return nil, ErrNotCompiled
+	}
+	return &Matcher[V]{sc: ac.newScanner(ac.kind, fn)}, nil
+}
+
+// MustNewMatcher 同 NewMatcher，出错时 panic
+func (ac *AutomationOf[V]) MustNewMatcher(fn func(Hit[V]) bool) *Matcher[V] {
+	m, err := ac.NewMatcher(fn)
+	must(err)
+	return m
+}
+
+// Reset 清空状态，之后的位置重新从 0 开始计算
+func (m *Matcher[V]) Reset() {
+	m.sc.reset()
+	m.partial = m.partial[:0]
+	m.stopped = false
+}
+
+// Pos 已读入的字符数
+func (m *Matcher[V]) Pos() int {
+	return m.sc.pos
+}
+
+// BytePos 已读入的字节数，不包括还不完整的字符
+func (m *Matcher[V]) BytePos() int {
+	return m.sc.bytePos
+}
+
+// Stopped 回调函数是否已经返回 false
+func (m *Matcher[V]) Stopped() bool {
+	return m.stopped
+}
+
+// Write 写入 UTF-8 文本，末尾不完整的字符留到下次写入时补全，总是返回 len(p), nil
+func (m *Matcher[V]) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(m.partial) > 0 {
+		k := len(m.partial)
+		take := len(p)
+		if take > utf8.UTFMax {
+			take = utf8.UTFMax
+		}
+		buff := append(m.partial, p[:take]...)
+		i := 0
+		for i < k {
+			if !utf8.FullRune(buff[i:]) {
+				// p 已经全部放进 buff 仍不完整
+				m.partial = append(m.partial[:0], buff[i:]...)
+				return n, nil
+			}
+			r, size := utf8.DecodeRune(buff[i:])
+			m.feed(r, size)
+			i += size
+		}
+		p = p[i-k:]
+		m.partial = m.partial[:0]
+	}
+	for len(p) > 0 && !m.stopped {
+		if !utf8.FullRune(p) {
+			m.partial = append(m.partial, p...)
+			break
+		}
+		r, size := utf8.DecodeRune(p)
+		m.feed(r, size)
+		p = p[size:]
+	}
+	return n, nil
+}
+
+// WriteString 同 Write
+func (m *Matcher[V]) WriteString(s string) (int, error) {
+	return m.Write([]byte(s))
+}
+
+// WriteRunes 写入字符
+func (m *Matcher[V]) WriteRunes(seq []rune) {
+	for _, r := range seq {
+		if m.stopped {
+			return
+		}
+		m.feed(r, runeSize(r))
+	}
+}
+
+// Close 结束输入，不完整的 UTF-8 字节按 utf8.RuneError 处理，并输出缓存的命中
+// 之后需要 Reset 才能继续使用
+func (m *Matcher[V]) Close() error {
+	for range m.partial {
+		m.feed(utf8.RuneError, 1)
+	}
+	m.partial = m.partial[:0]
+	if !m.stopped && !m.sc.finish() {
+		m.stopped = true
+	}
+	return nil
+}
+
+func (m *Matcher[V]) feed(r rune, size int) {
+	if !m.stopped && !m.sc.feed(r, size) {
+		m.stopped = true
+	}
+}
+
+// MatchReader 从 r 读取 UTF-8 文本直到 EOF 并匹配，fn 返回 false 时提前结束
+func (ac *AutomationOf[V]) MatchReader(r io.Reader, fn func(Hit[V]) bool) error {
+	m, err := ac.NewMatcher(fn)
+	if err != nil {
+		return err
+	}
+	buff := make([]byte, 32*1024)
+	for !m.stopped {
+		n, err := r.Read(buff)
+		if n > 0 {
+			_, _ = m.Write(buff[:n])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return m.Close()
+}
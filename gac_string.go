@@ -0,0 +1,50 @@
+package tools
+
+// MatchString 同 Match，直接匹配 UTF-8 字符串，不需要转换为 []rune
+// 命中同时包含字符位置和字节位置，s[ByteStartPoses[i]:ByteEndPoses[i]] 即命中的内容
+func (ac *AutomationOf[V]) MatchString(s string) (*IndexesInfo, error) {
+	return ac.collect(stringInput[V](s), 0)
+}
+
+// MustMatchString 同 MatchString，出错时 panic
+func (ac *AutomationOf[V]) MustMatchString(s string) *IndexesInfo {
+	return mustIndexes(ac.MatchString(s))
+}
+
+// MatchBytes 同 MatchString，非法的 UTF-8 字节按 utf8.RuneError 处理
+func (ac *AutomationOf[V]) MatchBytes(b []byte) (*IndexesInfo, error) {
+	return ac.collect(bytesInput[V](b), 0)
+}
+
+// MustMatchBytes 同 MatchBytes，出错时 panic
+func (ac *AutomationOf[V]) MustMatchBytes(b []byte) *IndexesInfo {
+	return mustIndexes(ac.MatchBytes(b))
+}
+
+// MatchStringFunc 同 MatchFunc，直接匹配 UTF-8 字符串
+func (ac *AutomationOf[V]) MatchStringFunc(s string, fn func(Hit[V]) bool) error {
+	if !ac.compiled {
+		return ErrNotCompiled
+	}
+	ac.matchFunc(stringInput[V](s), fn)
+	return nil
+}
+
+// MustMatchStringFunc 同 MatchStringFunc，出错时 panic
+func (ac *AutomationOf[V]) MustMatchStringFunc(s string, fn func(Hit[V]) bool) {
+	must(ac.MatchStringFunc(s, fn))
+}
+
+// MatchBytesFunc 同 MatchFunc，直接匹配 UTF-8 字节
+func (ac *AutomationOf[V]) MatchBytesFunc(b []byte, fn func(Hit[V]) bool) error {
+	if !ac.compiled {
+		return ErrNotCompiled
+	}
+	ac.matchFunc(bytesInput[V](b), fn)
+	return nil
+}
+
+// MustMatchBytesFunc 同 MatchBytesFunc，出错时 panic
+func (ac *AutomationOf[V]) MustMatchBytesFunc(b []byte, fn func(Hit[V]) bool) {
+	must(ac.MatchBytesFunc(b, fn))
+}
@@ -0,0 +1,1408 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+var testAlphabets = [][]rune{
+	[]rune("ab"),
+	[]rune("abc八婆"),
+	[]rune("中国人民𝒜𝒝"), // 包括 BMP 之外的字符
+}
+
+// randomWords 从 alphabet 中随机生成最多 n 个互不相同的模式串，长度为 1 到 maxLen
+func randomWords(rnd *rand.Rand, alphabet []rune, n, maxLen int) [][]rune {
+	var words [][]rune
+	seen := map[string]bool{}
+	for i := 0; i < n; i++ {
+		w := randomText(rnd, alphabet, 1+rnd.Intn(maxLen))
+		if !seen[string(w)] {
+			seen[string(w)] = true
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+func randomText(rnd *rand.Rand, alphabet []rune, n int) []rune {
+	text := make([]rune, n)
+	for i := range text {
+		text[i] = alphabet[rnd.Intn(len(alphabet))]
+	}
+	return text
+}
+
+// buildWords 插入 words，第 i 个模式串的 value 为 i
+func buildWords(t *testing.T, ac *AutomationOf[int], words [][]rune) *AutomationOf[int] {
+	t.Helper()
+	for i, w := range words {
+		if err := ac.Insert(w, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ac.Compile()
+	return ac
+}
+
+// bruteHits 暴力查找 words 在 text 中的全部出现，顺序与 MatchStandard 相同：
+// 按结束位置排列，结束位置相同时长的在前
+func bruteHits(words [][]rune, text []rune) []Hit[int] {
+	return bruteEquiv(words, text, nil)
+}
+
+// bruteEquiv 同 bruteHits，文本字符还可以匹配它在 c 中代替的字符
+func bruteEquiv(words [][]rune, text []rune, c Confusables) []Hit[int] {
+	offs := make([]int, len(text)+1)
+	for i, r := range text {
+		offs[i+1] = offs[i] + runeSize(r)
+	}
+	var hits []Hit[int]
+	for i, w := range words {
+		for s := 0; s+len(w) <= len(text); s++ {
+			if equivAt(text[s:], w, c) {
+				e := s + len(w)
+				hits = append(hits, Hit[int]{
+					Index: i, Start: s, End: e, ByteStart: offs[s], ByteEnd: offs[e], Value: i,
+				})
+			}
+		}
+	}
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].End != hits[b].End {
+			return hits[a].End < hits[b].End
+		}
+		if hits[a].Start != hits[b].Start {
+			return hits[a].Start < hits[b].Start
+		}
+		return hits[a].Index < hits[b].Index
+	})
+	return hits
+}
+
+func equivAt(text, w []rune, c Confusables) bool {
+	for i, p := range w {
+		if text[i] != p && !containsRune(c[text[i]], p) {
+			return false
+		}
+	}
+	return true
+}
+
+// collectHits 收集 MatchFunc 的全部命中，只保留与 bruteHits 可比较的字段
+func collectHits(t *testing.T, ac *AutomationOf[int], text []rune) []Hit[int] {
+	t.Helper()
+	var hits []Hit[int]
+	err := ac.MatchFunc(text, func(h Hit[int]) bool {
+		h.Values = nil
+		hits = append(hits, h)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hits
+}
+
+func TestBackendEquivalence(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for iter := 0; iter < 300; iter++ {
+		alphabet := testAlphabets[iter%len(testAlphabets)]
+		words := randomWords(rnd, alphabet, 1+rnd.Intn(20), 5)
+		m := buildWords(t, GenAutomationOf[int]().SetBackend(BackendMap), words)
+		d := buildWords(t, GenAutomationOf[int]().SetBackend(BackendDoubleArray), words)
+		for k := 0; k < 5; k++ {
+			text := randomText(rnd, alphabet, rnd.Intn(40))
+			want := bruteHits(words, text)
+			if got := collectHits(t, m, text); !reflect.DeepEqual(got, want) {
+				t.Fatalf("map backend, text %q:\n got %v\nwant %v", string(text), got, want)
+			}
+			if got := collectHits(t, d, text); !reflect.DeepEqual(got, want) {
+				t.Fatalf("double array backend, text %q:\n got %v\nwant %v", string(text), got, want)
+			}
+			r := d.MustMatch(text)
+			if r.Len != len(want) {
+				t.Fatalf("Match returns %d hits, want %d", r.Len, len(want))
+			}
+			for i, h := range want {
+				if r.Indexes[i] != h.Index || r.StartPoses[i] != h.Start || r.EndPoses[i] != h.End-1 {
+					t.Fatalf("Match hit %d: got (%d, %d, %d), want %v", i, r.Indexes[i], r.StartPoses[i], r.EndPoses[i], h)
+				}
+			}
+			d.PoolPut(r)
+		}
+	}
+}
+
+// TestBackendWideAlphabet 字母表很大时双数组需要为很多字符分配编码和槽位
+func TestBackendWideAlphabet(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	var alphabet []rune
+	for r := rune(0x4E00); r < 0x4E00+3000; r++ {
+		alphabet = append(alphabet, r)
+	}
+	alphabet = append(alphabet, 0x1F600, 0x1F601, 0x20000)
+	words := randomWords(rnd, alphabet, 5000, 4)
+	m := buildWords(t, GenAutomationOf[int]().SetBackend(BackendMap), words)
+	d := buildWords(t, GenAutomationOf[int]().SetBackend(BackendDoubleArray), words)
+	for k := 0; k < 50; k++ {
+		// 从模式串中截取文本，保证有命中
+		var text []rune
+		for len(text) < 100 {
+			text = append(text, words[rnd.Intn(len(words))]...)
+			text = append(text, alphabet[rnd.Intn(len(alphabet))])
+		}
+		want := collectHits(t, m, text)
+		if len(want) == 0 {
+			t.Fatal("no hits")
+		}
+		if got := collectHits(t, d, text); !reflect.DeepEqual(got, want) {
+			t.Fatalf("backends disagree on %q", string(text))
+		}
+	}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	for iter := 0; iter < 100; iter++ {
+		alphabet := testAlphabets[iter%len(testAlphabets)]
+		words := randomWords(rnd, alphabet, 1+rnd.Intn(20), 5)
+		ac := buildWords(t, GenAutomationOf[int]().SetBackend(Backend(iter%2)), words)
+		data, err := ac.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 恢复时可以换一种 Backend
+		back := GenAutomationOf[int]().SetBackend(Backend(1 - iter%2))
+		if err := back.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		for k := 0; k < 5; k++ {
+			text := randomText(rnd, alphabet, rnd.Intn(40))
+			if got, want := collectHits(t, back, text), bruteHits(words, text); !reflect.DeepEqual(got, want) {
+				t.Fatalf("text %q:\n got %v\nwant %v", string(text), got, want)
+			}
+		}
+		again, err := back.MarshalBinary()
+		if err != nil || !bytes.Equal(again, data) {
+			t.Fatalf("marshal after unmarshal differs: %v", err)
+		}
+	}
+}
+
+func TestBinaryEmpty(t *testing.T) {
+	empty := GenAutomation()
+	empty.Compile()
+	for _, ac := range []*Automation{empty, GenHotAutomation(nil).Load()} {
+		buff := &bytes.Buffer{}
+		if _, err := ac.WriteTo(buff); err != nil {
+			t.Fatal(err)
+		}
+		back := GenAutomation()
+		if _, err := back.ReadFrom(buff); err != nil {
+			t.Fatal(err)
+		}
+		if r := back.MustMatchString("anything"); r.Len != 0 {
+			t.Fatalf("empty automaton matches %d hits", r.Len)
+		}
+	}
+}
+
+func TestBinaryCorrupted(t *testing.T) {
+	ac := buildWords(t, GenAutomationOf[int](), [][]rune{[]rune("he"), []rune("she"), []rune("his")})
+	data, err := ac.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; n < len(data); n++ {
+		if err := GenAutomationOf[int]().UnmarshalBinary(data[:n]); err == nil {
+			t.Fatalf("truncated to %d bytes: no error", n)
+		}
+	}
+	for i := range data {
+		bad := append([]byte(nil), data...)
+		bad[i] ^= 0x40
+		if err := GenAutomationOf[int]().UnmarshalBinary(bad); err == nil {
+			t.Fatalf("byte %d flipped: no error", i)
+		}
+	}
+	if err := GenAutomationOf[int]().SetNormalizer(DefaultNormalizer).UnmarshalBinary(data); err == nil {
+		t.Fatal("normalizer mismatch: no error")
+	}
+}
+
+// bruteLeftmost 从 bruteHits 中按 kind 选出不重叠的命中
+func bruteLeftmost(words [][]rune, text []rune, kind MatchKind) []Hit[int] {
+	all := bruteHits(words, text)
+	var hits []Hit[int]
+	for pos := 0; ; {
+		best := -1
+		for i, h := range all {
+			if h.Start < pos {
+				continue
+			}
+			if best < 0 || h.Start < all[best].Start {
+				best = i
+				continue
+			}
+			if h.Start > all[best].Start {
+				continue
+			}
+			if kind == MatchLeftmostLongest && h.End > all[best].End ||
+				kind == MatchLeftmostFirst && h.Index < all[best].Index {
+				best = i
+			}
+		}
+		if best < 0 {
+			return hits
+		}
+		hits = append(hits, all[best])
+		pos = all[best].End
+	}
+}
+
+func TestMatchKinds(t *testing.T) {
+	rnd := rand.New(rand.NewSource(4))
+	for iter := 0; iter < 300; iter++ {
+		alphabet := testAlphabets[iter%len(testAlphabets)]
+		words := randomWords(rnd, alphabet, 1+rnd.Intn(12), 4)
+		for _, kind := range []MatchKind{MatchLeftmostFirst, MatchLeftmostLongest} {
+			ac := buildWords(t, GenAutomationOf[int]().SetMatchKind(kind).SetBackend(Backend(iter%2)), words)
+			for k := 0; k < 5; k++ {
+				text := randomText(rnd, alphabet, rnd.Intn(40))
+				want := bruteLeftmost(words, text, kind)
+				if got := collectHits(t, ac, text); !reflect.DeepEqual(got, want) {
+					t.Fatalf("kind %d, words %q, text %q:\n got %v\nwant %v", kind, words, string(text), got, want)
+				}
+				if len(want) == 0 {
+					continue
+				}
+				r := ac.MustMatchLimit(text, len(want)-1)
+				if len(want) > 1 && (r.Len != len(want)-1 || !r.Truncated) {
+					t.Fatalf("MatchLimit: %d hits, truncated %v", r.Len, r.Truncated)
+				}
+				ac.PoolPut(r)
+			}
+		}
+	}
+}
+
+func TestMatcherChunks(t *testing.T) {
+	rnd := rand.New(rand.NewSource(5))
+	for iter := 0; iter < 200; iter++ {
+		alphabet := testAlphabets[iter%len(testAlphabets)]
+		words := randomWords(rnd, alphabet, 1+rnd.Intn(12), 4)
+		kind := MatchKind(iter % 3)
+		ac := buildWords(t, GenAutomationOf[int]().SetMatchKind(kind), words)
+		text := randomText(rnd, alphabet, rnd.Intn(60))
+		want := collectHits(t, ac, text)
+		var got []Hit[int]
+		m := ac.MustNewMatcher(func(h Hit[int]) bool {
+			h.Values = nil
+			got = append(got, h)
+			return true
+		})
+		// 随机切分字节，跨越写入的字符和模式串都要能命中
+		data := []byte(string(text))
+		for len(data) > 0 {
+			n := 1 + rnd.Intn(minInt(len(data), 7))
+			m.Write(data[:n])
+			data = data[n:]
+		}
+		m.Close()
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("kind %d, text %q:\n got %v\nwant %v", kind, string(text), got, want)
+		}
+
+		got = got[:0]
+		m.Reset()
+		m.WriteRunes(text)
+		m.Close()
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("WriteRunes, text %q:\n got %v\nwant %v", string(text), got, want)
+		}
+
+		got = got[:0]
+		err := ac.MatchReader(iotest.OneByteReader(bytes.NewReader([]byte(string(text)))), func(h Hit[int]) bool {
+			h.Values = nil
+			got = append(got, h)
+			return true
+		})
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Fatalf("MatchReader, text %q: %v\n got %v\nwant %v", string(text), err, got, want)
+		}
+	}
+}
+
+func TestMatcherStop(t *testing.T) {
+	ac := buildWords(t, GenAutomationOf[int](), [][]rune{[]rune("a")})
+	n := 0
+	m := ac.MustNewMatcher(func(h Hit[int]) bool {
+		n++
+		return n < 2
+	})
+	m.WriteString("aaaa")
+	m.Close()
+	if n != 2 || !m.Stopped() {
+		t.Fatalf("%d hits after stop, stopped %v", n, m.Stopped())
+	}
+	if m.Pos() != 2 || m.BytePos() != 2 {
+		t.Fatalf("stopped at %d/%d", m.Pos(), m.BytePos())
+	}
+}
+
+type testRule struct {
+	Name  string
+	Lines []int
+}
+
+// TestBinaryTypedValues 自定义类型不需要 gob.Register，nil 指针恢复为 nil
+func TestBinaryTypedValues(t *testing.T) {
+	rules := GenAutomationOf[testRule]()
+	rules.MustInsert([]rune("八婆"), testRule{Name: "insult", Lines: []int{1, 3}})
+	rules.MustInsert([]rune("胖"), testRule{})
+	rules.Compile()
+	data, err := rules.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	back := GenAutomationOf[testRule]()
+	if err := back.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		_, want := rules.MustGetMatched(i)
+		if _, got := back.MustGetMatched(i); !reflect.DeepEqual(got, want) {
+			t.Fatalf("value %d: got %v, want %v", i, got, want)
+		}
+	}
+
+	ptrs := GenAutomationOf[*testRule]()
+	ptrs.MustInsert([]rune("a"), nil)
+	ptrs.MustInsert([]rune("b"), &testRule{Name: "b"})
+	ptrs.Compile()
+	data, err = ptrs.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pback := GenAutomationOf[*testRule]()
+	if err := pback.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if _, v := pback.MustGetMatched(0); v != nil {
+		t.Fatalf("nil pointer restored as %v", v)
+	}
+	if _, v := pback.MustGetMatched(1); v == nil || v.Name != "b" {
+		t.Fatalf("pointer restored as %v", v)
+	}
+
+	strs := GenAutomationOf[string]().SetValueCodec(StringValueCodec)
+	strs.MustInsert([]rune("x"), "")
+	strs.MustInsert([]rune("y"), "why")
+	strs.Compile()
+	data, err = strs.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sback := GenAutomationOf[string]().SetValueCodec(StringValueCodec)
+	if err := sback.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if _, v := sback.MustGetMatched(1); v != "why" {
+		t.Fatalf("string value restored as %q", v)
+	}
+}
+
+// TestDefaultNormalizer 预组合字符与组合序列归一化结果相同，通用变音符号被去掉，
+// 文字自己的组合字符保留，命中位置为原文位置
+func TestDefaultNormalizer(t *testing.T) {
+	ac := GenAutomationOf[int]().SetNormalizer(DefaultNormalizer)
+	patterns := []string{"café", "fuck", "हिंदी", "한국", "ที่"}
+	for i, p := range patterns {
+		ac.MustInsert([]rune(p), i)
+	}
+	ac.Compile()
+	cases := []struct {
+		text  string
+		index int // -1 表示不能命中
+		start int
+		end   int
+	}{
+		{"un café", 0, 3, 7},
+		{"un cafe\u0301!", 0, 3, 7}, // 末尾被去掉的组合字符不算在命中内
+		{"CAFÉ", 0, 0, 4},
+		{"cafe", 0, 0, 4},
+		{"ＦＵＣＫ", 1, 0, 4},
+		{"Ⓕⓤⓒⓚ", 1, 0, 4},
+		{"f\u200bu\u200dc\u0336k", 1, 0, 7},
+		{"𝐟𝐮𝐜𝐤", 1, 0, 4},
+		{"हिंदी", 2, 0, 5},
+		{"हिदी", -1, 0, 0},
+		{"대한국", 3, 1, 3},
+		{"\u1112\u1161\u11ab\u1100\u116e\u11a8", 3, 0, 6},
+		{"ที่", 4, 0, 3},
+		{"ที", -1, 0, 0},
+	}
+	for _, c := range cases {
+		r := ac.MustMatchString(c.text)
+		if c.index < 0 {
+			if r.Len != 0 {
+				t.Errorf("%q: unexpected hit %d", c.text, r.Indexes[0])
+			}
+		} else if r.Len != 1 || r.Indexes[0] != c.index || r.StartPoses[0] != c.start || r.EndPoses[0] != c.end-1 {
+			t.Errorf("%q: got %d hits %v %v %v, want %d [%d, %d)", c.text, r.Len,
+				r.Indexes[:r.Len], r.StartPoses[:r.Len], r.EndPoses[:r.Len], c.index, c.start, c.end)
+		}
+		ac.PoolPut(r)
+	}
+}
+
+func TestTraditionalToSimplified(t *testing.T) {
+	pairs := strings.Fields(tsCharacters)
+	m := TraditionalToSimplified()
+	if len(m) != len(pairs) {
+		t.Fatalf("%d pairs but %d distinct characters", len(pairs), len(m))
+	}
+	for _, p := range pairs {
+		if rs := []rune(p); len(rs) != 2 || m[rs[0]] != rs[1] {
+			t.Fatalf("bad pair %q", p)
+		}
+	}
+	ac := GenAutomationOf[int]().SetNormalizer(m)
+	ac.MustInsert([]rune("这个时候"), 0)
+	ac.Compile()
+	if r := ac.MustMatchString("在這個時候"); r.Len != 1 || r.StartPoses[0] != 1 {
+		t.Fatalf("got %d hits", r.Len)
+	}
+}
+
+func TestConfusables(t *testing.T) {
+	ac := GenAutomationOf[int]().SetNormalizer(DefaultNormalizer).SetConfusables(DefaultConfusables())
+	for i, w := range []string{"shit", "110", "hello", "he11o", "paypal"} {
+		ac.MustInsert([]rune(w), i)
+	}
+	ac.Compile()
+	for _, c := range []struct {
+		text  string
+		index []int
+	}{
+		{"sh1t", []int{0}},
+		{"SH!T", []int{0}},
+		{"HELLO", []int{2}},
+		{"he11o", []int{2, 3}},
+		{"110", []int{1}},
+		{"llo", nil},
+		{"раураl", []int{4}}, // 西里尔字母
+		{"Р@YР4|", []int{4}},
+	} {
+		var got []int
+		ac.MustMatchStringFunc(c.text, func(h Hit[int]) bool {
+			got = append(got, h.Index)
+			return true
+		})
+		sort.Ints(got)
+		if !reflect.DeepEqual(got, c.index) {
+			t.Errorf("%q: got %v, want %v", c.text, got, c.index)
+		}
+	}
+	if h, ok := ac.MustLongestPrefix([]rune("he11o!")); !ok || h.Index != 2 || h.End != 5 {
+		t.Errorf("LongestPrefix: got %v %v", h, ok)
+	}
+	if hits := ac.MustMatchFuzzy([]rune("pay9al"), FuzzyOptions{MaxDistance: 1}); len(hits) != 1 || hits[0].Index != 4 || hits[0].Distance != 1 {
+		t.Errorf("MatchFuzzy: got %v", hits)
+	}
+
+	// 文本中的字符可能有多种读法，与逐个位置比较的结果相同
+	rnd := rand.New(rand.NewSource(4))
+	alphabet := []rune("ab01")
+	conf := Confusables{}.Add('0', 'a', 'b').Add('1', 'b')
+	for iter := 0; iter < 300; iter++ {
+		words := randomWords(rnd, alphabet, 1+rnd.Intn(20), 5)
+		for _, backend := range []Backend{BackendMap, BackendDoubleArray} {
+			ac := buildWords(t, GenAutomationOf[int]().SetBackend(backend).SetConfusables(conf), words)
+			text := randomText(rnd, alphabet, rnd.Intn(40))
+			want := bruteEquiv(words, text, conf)
+			if got := collectHits(t, ac, text); !reflect.DeepEqual(got, want) {
+				t.Fatalf("words %q, text %q:\n got %v\nwant %v", words, string(text), got, want)
+			}
+		}
+	}
+}
+
+// editDistance 两个字符串的 Levenshtein 距离
+func editDistance(a, b []rune) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		diag := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			v := minInt(row[j], row[j-1]) + 1
+			if a[i-1] == b[j-1] {
+				v = minInt(v, diag)
+			} else {
+				v = minInt(v, diag+1)
+			}
+			diag, row[j] = row[j], v
+		}
+	}
+	return row[len(b)]
+}
+
+// anchoredDistance w 与 span 的编辑距离，要求 span 的第一个字符与 w 的前 k+1 个字符中的某一个相同
+func anchoredDistance(w, span []rune, k int) int {
+	best := -1
+	for i := 0; i <= k && i < len(w); i++ {
+		if w[i] == span[0] {
+			if d := i + editDistance(w[i+1:], span[1:]); best < 0 || d < best {
+				best = d
+			}
+		}
+	}
+	return best
+}
+
+func TestMatchFuzzy(t *testing.T) {
+	ac := GenAutomationOf[int]()
+	for i, w := range []string{"hello", "ab", "world"} {
+		ac.MustInsert([]rune(w), i)
+	}
+	ac.Compile()
+	for _, c := range []struct {
+		text string
+		opts FuzzyOptions
+		want []Hit[int] // 只比较 Index、Start、End、Distance
+	}{
+		{"say helo", FuzzyOptions{MaxDistance: 1}, []Hit[int]{{Index: 0, Start: 4, End: 8, Distance: 1}}},
+		{"say hxllo", FuzzyOptions{MaxDistance: 1}, []Hit[int]{{Index: 0, Start: 4, End: 9, Distance: 1}}},
+		{"say ello", FuzzyOptions{MaxDistance: 1}, []Hit[int]{{Index: 0, Start: 4, End: 8, Distance: 1}}},
+		{"wrold", FuzzyOptions{MaxDistance: 2}, []Hit[int]{{Index: 2, Start: 0, End: 5, Distance: 2}}},
+		{"wrold", FuzzyOptions{MaxDistance: 2, MinLength: 6}, nil},
+		{"xb ab", FuzzyOptions{MaxDistance: 1}, []Hit[int]{{Index: 1, Start: 3, End: 5}}},
+		{"xb ab", FuzzyOptions{MaxDistance: 1, MinLength: 1}, []Hit[int]{{Index: 1, Start: 1, End: 2, Distance: 1}, {Index: 1, Start: 3, End: 5}}},
+		{"xb hallo", FuzzyOptions{MaxDistance: 1, MinLengthOf: func(index int) int { return 10 * index }}, []Hit[int]{{Index: 0, Start: 3, End: 8, Distance: 1}}},
+	} {
+		var got []Hit[int]
+		for _, h := range ac.MustMatchFuzzyString(c.text, c.opts) {
+			got = append(got, Hit[int]{Index: h.Index, Start: h.Start, End: h.End, Distance: h.Distance})
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q %+v: got %v, want %v", c.text, c.opts, got, c.want)
+		}
+	}
+
+	// 与逐个位置计算编辑距离的结果比较
+	rnd := rand.New(rand.NewSource(5))
+	for iter := 0; iter < 200; iter++ {
+		alphabet := testAlphabets[iter%len(testAlphabets)]
+		words := randomWords(rnd, alphabet, 1+rnd.Intn(10), 7)
+		ac := buildWords(t, GenAutomationOf[int](), words)
+		text := randomText(rnd, alphabet, rnd.Intn(30))
+		k := 1 + iter%2
+		minLen := 2*k + 1
+		hits := ac.MustMatchFuzzy(text, FuzzyOptions{MaxDistance: k})
+		for _, h := range hits {
+			w := words[h.Index]
+			d := anchoredDistance(w, text[h.Start:h.End], k)
+			if d != h.Distance || d > k || d > 0 && len(w) < minLen {
+				t.Fatalf("%q in %q: bad hit %v, distance %d", string(w), string(text), h, d)
+			}
+		}
+		for i, w := range words {
+			for s := range text {
+				for e := s + 1; e <= len(text); e++ {
+					d := anchoredDistance(w, text[s:e], k)
+					if d < 0 || d > k || d > 0 && len(w) < minLen {
+						continue
+					}
+					found := false
+					for _, h := range hits {
+						if h.Index == i && h.Distance <= d && h.Start < s+len(w)+k && s < h.End {
+							found = true
+						}
+					}
+					if !found {
+						t.Fatalf("%q in %q: missing [%d, %d) distance %d, got %v", string(w), string(text), s, e, d, hits)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestPinyinVariants(t *testing.T) {
+	pa := GenPinyinAutomationOf[int](nil, PinyinFull|PinyinInitials)
+	pa.MustInsert([]rune("八婆"), 0)
+	pa.MustInsert([]rune("中国"), 1)
+	pa.Compile()
+	for _, c := range []struct {
+		text  string
+		index []int
+	}{
+		{"你个八婆", []int{0}},
+		{"ba po", []int{0}},
+		{"bapo!", []int{0}},
+		{"BP", []int{0}},
+		{"kebapolitan", nil},
+		{"bpm", nil},
+		{"the zgxx", nil},
+		{"我爱zg", []int{1}},
+		{"zhong guo", []int{1}},
+	} {
+		var got []int
+		pa.MustMatchStringFunc(c.text, func(h Hit[int]) bool {
+			got = append(got, h.Index)
+			return true
+		})
+		if !reflect.DeepEqual(got, c.index) {
+			t.Errorf("%q: got %v, want %v", c.text, got, c.index)
+		}
+	}
+	if hits := pa.Automation().MustMatchFuzzyString("kebapolitan", FuzzyOptions{MaxDistance: 1}); len(hits) != 0 {
+		t.Errorf("fuzzy pinyin variants should need word boundaries, got %v", hits)
+	}
+}
+
+func TestWordBoundary(t *testing.T) {
+	ac := GenAutomationOf[int]()
+	ac.MustInsertWord([]rune("ass"), 0)
+	ac.MustInsertWord([]rune("国"), 1)
+	ac.Compile()
+	for _, c := range []struct {
+		text, want string
+	}{
+		{"first class", "first class"},
+		{"an ass", "an ***"}, // 输入结束时等待中的命中也要输出
+		{"ass, and ass", "***, and ***"},
+		{"中国人", "中*人"},
+	} {
+		if got := ac.MustMaskString(c.text, '*'); got != c.want {
+			t.Errorf("MaskString(%q) = %q, want %q", c.text, got, c.want)
+		}
+		if got := string(ac.MustMask([]rune(c.text), '*')); got != c.want {
+			t.Errorf("Mask(%q) = %q, want %q", c.text, got, c.want)
+		}
+		got := ac.MustReplaceAllString(c.text, func(h Hit[int]) string {
+			return strings.Repeat("*", h.End-h.Start)
+		})
+		if got != c.want {
+			t.Errorf("ReplaceAllString(%q) = %q, want %q", c.text, got, c.want)
+		}
+	}
+
+	// 近似匹配同样检查单词边界
+	for _, c := range []struct {
+		text string
+		want []Hit[int] // 只比较 Index、Start、End、Distance
+	}{
+		{"first class", nil},
+		{"assistant", nil},
+		{"an ass", []Hit[int]{{Index: 0, Start: 3, End: 6}}},
+		{"an asss!", []Hit[int]{{Index: 0, Start: 3, End: 7, Distance: 1}}},
+	} {
+		var got []Hit[int]
+		for _, h := range ac.MustMatchFuzzyString(c.text, FuzzyOptions{MaxDistance: 1}) {
+			got = append(got, Hit[int]{Index: h.Index, Start: h.Start, End: h.End, Distance: h.Distance})
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("MatchFuzzyString(%q) = %v, want %v", c.text, got, c.want)
+		}
+	}
+}
+
+func TestSetAfterInsert(t *testing.T) {
+	ac := GenAutomationOf[int]()
+	ac.MustInsert([]rune("ab"), 0)
+	if err := ac.SetNormalizer(DefaultNormalizer).Insert([]rune("CD"), 1); err != ErrInserted {
+		t.Fatalf("Insert after SetNormalizer: got %v, want ErrInserted", err)
+	}
+
+	// 常见的顺序：插入全部模式串，设置，再 Compile
+	ac = GenAutomationOf[int]()
+	ac.MustInsert([]rune("ab"), 0)
+	if err := ac.SetNormalizer(DefaultNormalizer).Compile(); err != ErrInserted {
+		t.Fatalf("Compile after SetNormalizer: got %v, want ErrInserted", err)
+	}
+	if _, err := ac.MatchString("AB"); err != ErrNotCompiled {
+		t.Fatalf("Match: got %v, want ErrNotCompiled", err)
+	}
+
+	ac = GenAutomationOf[int]()
+	ac.MustInsert([]rune("ab"), 0)
+	if err := ac.SetSkip(SkipSpace, 1).Compile(); err != ErrInserted {
+		t.Fatalf("Compile after SetSkip: got %v, want ErrInserted", err)
+	}
+
+	pa := GenPinyinAutomationOf[int](nil, PinyinFull)
+	pa.MustInsert([]rune("八婆"), 0)
+	pa.Automation().SetSkip(nil, 0)
+	if err := pa.Compile(); err != ErrInserted {
+		t.Fatalf("PinyinAutomation Compile: got %v, want ErrInserted", err)
+	}
+
+	ac = GenAutomationOf[int]().SetNormalizer(DefaultNormalizer)
+	ac.MustInsert([]rune("ab"), 0)
+	ac.MustCompile()
+	if r := ac.MustMatchString("AB"); r.Len != 1 {
+		t.Fatalf("got %d hits", r.Len)
+	}
+}
+
+// bruteMask 把 bruteHits 的全部命中覆盖到的字符替换为 '*'
+func bruteMask(words [][]rune, text []rune) string {
+	out := append([]rune(nil), text...)
+	for _, h := range bruteHits(words, text) {
+		for i := h.Start; i < h.End; i++ {
+			out[i] = '*'
+		}
+	}
+	return string(out)
+}
+
+func TestMask(t *testing.T) {
+	ac := buildWords(t, GenAutomationOf[int](), [][]rune{[]rune("cd"), []rune("abcde")})
+	if got := ac.MustMaskString("xabcdey", '*'); got != "x*****y" {
+		t.Fatalf("got %q", got)
+	}
+
+	rnd := rand.New(rand.NewSource(6))
+	for iter := 0; iter < 2000; iter++ {
+		alphabet := testAlphabets[iter%len(testAlphabets)]
+		words := randomWords(rnd, alphabet, 1+rnd.Intn(10), 6)
+		ac := buildWords(t, GenAutomationOf[int](), words)
+		text := randomText(rnd, alphabet, rnd.Intn(40))
+		want := bruteMask(words, text)
+		if got := string(ac.MustMask(text, '*')); got != want {
+			t.Fatalf("Mask %q with %q: got %q, want %q", string(text), words, got, want)
+		}
+		if got := ac.MustMaskString(string(text), '*'); got != want {
+			t.Fatalf("MaskString %q with %q: got %q, want %q", string(text), words, got, want)
+		}
+	}
+}
+
+func TestHotAutomation(t *testing.T) {
+	h := GenHotAutomationOf[int](nil)
+	defer h.Close()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(2)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				w := []rune(fmt.Sprintf("w%d-%d", g, i))
+				h.Insert(w, i)
+				if i%2 == 1 {
+					h.Remove(w)
+				}
+			}
+		}(g)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				ac := h.Load()
+				r := ac.MustMatchString("w0-10 w1-20 w2-30")
+				for k := 0; k < r.Len; k++ {
+					ac.MustGetMatched(r.Indexes[k])
+				}
+				ac.PoolPut(r)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := h.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	if h.Len() != 400 {
+		t.Fatalf("Len = %d, want 400", h.Len())
+	}
+	r := h.Load().MustMatchString("w0-10 w1-33 w3-198")
+	if r.Len != 2 {
+		t.Fatalf("got %d hits, want 2", r.Len)
+	}
+
+	h.Insert([]rune("new"), 1)
+	h.Remove([]rune("w0-10"))
+	if err := h.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	if r := h.Load().MustMatchString("new w0-10"); r.Len != 1 || r.Indexes[0] < 0 {
+		t.Fatalf("got %d hits, want 1", r.Len)
+	}
+}
+
+// TestHotAutomationBuildUnlocked 重建期间 Insert、Remove、Len 不会被阻塞
+func TestHotAutomationBuildUnlocked(t *testing.T) {
+	var block int32
+	building, resume := make(chan struct{}), make(chan struct{})
+	h := GenHotAutomationOf[int](func() *AutomationOf[int] {
+		if atomic.CompareAndSwapInt32(&block, 1, 0) {
+			building <- struct{}{}
+			<-resume
+		}
+		return GenAutomationOf[int]()
+	})
+	defer h.Close()
+	h.Insert([]rune("ab"), 0)
+	atomic.StoreInt32(&block, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- h.Rebuild()
+	}()
+	<-building
+	updated := make(chan int)
+	go func() {
+		h.Insert([]rune("cd"), 1)
+		h.Remove([]rune("ab"))
+		updated <- h.Len()
+	}()
+	select {
+	case n := <-updated:
+		if n != 1 {
+			t.Errorf("Len = %d, want 1", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Insert blocked while rebuilding")
+	}
+	close(resume)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	if r := h.Load().MustMatchString("ab cd"); r.Len != 1 || r.StartPoses[0] != 3 {
+		t.Fatalf("got %d hits, want cd only", r.Len)
+	}
+}
+
+func TestDuplicatePolicy(t *testing.T) {
+	inserts := []struct {
+		data  string
+		value int
+	}{{"foo", 1}, {"FOO", 2}, {"bar", 3}, {"Foo", 4}}
+	for _, c := range []struct {
+		policy DuplicatePolicy
+		data   string
+		values []int
+	}{
+		{DuplicateReplace, "Foo", []int{4}},
+		{DuplicateKeepFirst, "foo", []int{1}},
+		{DuplicateAccumulate, "foo", []int{1, 2, 4}},
+	} {
+		ac := GenAutomationOf[int]().SetNormalizer(DefaultNormalizer).SetDuplicatePolicy(c.policy)
+		for _, in := range inserts {
+			ac.MustInsert([]rune(in.data), in.value)
+		}
+		ac.MustCompile()
+		if st := ac.MustStats(); st.Patterns != 2 {
+			t.Errorf("policy %d: %d patterns, want 2", c.policy, st.Patterns)
+		}
+		if _, _, err := ac.GetMatched(2); err != ErrBadIndex {
+			t.Errorf("policy %d: GetMatched(2) = %v, want ErrBadIndex", c.policy, err)
+		}
+		data, value := ac.MustGetMatched(0)
+		if string(data) != c.data || value != c.values[0] {
+			t.Errorf("policy %d: GetMatched(0) = %q %d", c.policy, string(data), value)
+		}
+		if data, values := ac.MustGetMatchedAll(0); string(data) != c.data || !reflect.DeepEqual(values, c.values) {
+			t.Errorf("policy %d: GetMatchedAll(0) = %q %v, want %q %v", c.policy, string(data), values, c.data, c.values)
+		}
+		if _, values := ac.MustGetMatchedAll(1); !reflect.DeepEqual(values, []int{3}) {
+			t.Errorf("policy %d: GetMatchedAll(1) values = %v", c.policy, values)
+		}
+		var hits []Hit[int]
+		ac.MustMatchStringFunc("fOo bar", func(h Hit[int]) bool {
+			hits = append(hits, h)
+			return true
+		})
+		if len(hits) != 2 || hits[0].Index != 0 || hits[0].Value != c.values[0] || !reflect.DeepEqual(hits[0].Values, c.values) {
+			t.Errorf("policy %d: got hits %v", c.policy, hits)
+		}
+	}
+}
+
+func TestBinaryAccumulated(t *testing.T) {
+	ac := GenAutomationOf[int]().SetDuplicatePolicy(DuplicateAccumulate)
+	for i, w := range []string{"ab", "cd", "ab", "ab", "ef"} {
+		ac.MustInsert([]rune(w), i)
+	}
+	ac.MustCompile()
+	data, err := ac.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	back := GenAutomationOf[int]()
+	if err := back.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	for index, want := range [][]int{{0, 2, 3}, {1}, {4}} {
+		if _, values := back.MustGetMatchedAll(index); !reflect.DeepEqual(values, want) {
+			t.Errorf("GetMatchedAll(%d) = %v, want %v", index, values, want)
+		}
+	}
+	var got [][]int
+	back.MustMatchStringFunc("abef", func(h Hit[int]) bool {
+		got = append(got, h.Values)
+		return true
+	})
+	if !reflect.DeepEqual(got, [][]int{{0, 2, 3}, {4}}) {
+		t.Errorf("Hit.Values = %v", got)
+	}
+}
+
+func TestSkip(t *testing.T) {
+	ac := GenAutomationOf[int]().SetSkip(SkipAny(SkipSpace, SkipPunct, SkipEmoji), 2)
+	ac.MustInsert([]rune("八-婆"), 0) // 模式串中的噪声字符同样被去掉
+	ac.MustInsert([]rune("ab"), 1)
+	ac.MustCompile()
+	for _, c := range []struct {
+		text string
+		want []Hit[int] // 只比较 Index 和位置
+	}{
+		{"八婆", []Hit[int]{{Index: 0, Start: 0, End: 2, ByteStart: 0, ByteEnd: 6}}},
+		{"你个八*婆", []Hit[int]{{Index: 0, Start: 2, End: 5, ByteStart: 6, ByteEnd: 13}}},
+		{"八 婆", []Hit[int]{{Index: 0, Start: 0, End: 3, ByteStart: 0, ByteEnd: 7}}},
+		{"八😀 婆", []Hit[int]{{Index: 0, Start: 0, End: 4, ByteStart: 0, ByteEnd: 11}}},
+		{" *八婆!", []Hit[int]{{Index: 0, Start: 2, End: 4, ByteStart: 2, ByteEnd: 8}}},
+		{"八 * 婆", nil}, // 连续跳过 3 个字符，超过 maxGap
+		{"a.b a . b", []Hit[int]{{Index: 1, Start: 0, End: 3, ByteStart: 0, ByteEnd: 3}}},
+	} {
+		var got []Hit[int]
+		ac.MustMatchStringFunc(c.text, func(h Hit[int]) bool {
+			got = append(got, Hit[int]{Index: h.Index, Start: h.Start, End: h.End, ByteStart: h.ByteStart, ByteEnd: h.ByteEnd})
+			return true
+		})
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q: got %v, want %v", c.text, got, c.want)
+		}
+	}
+
+	// maxGap <= 0 时不限制
+	ac = GenAutomationOf[int]().SetSkip(SkipSpace, 0)
+	ac.MustInsert([]rune("ab"), 0)
+	ac.MustCompile()
+	if r := ac.MustMatchString("a     b"); r.Len != 1 || r.StartPoses[0] != 0 || r.EndPoses[0] != 6 {
+		t.Errorf("got %d hits", r.Len)
+	}
+}
+
+func TestMatchBatch(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	alphabet := testAlphabets[1]
+	words := randomWords(rnd, alphabet, 20, 4)
+	ac := buildWords(t, GenAutomationOf[int]().SetBatchWorkers(4), words)
+	texts := make([]string, 200)
+	for i := range texts {
+		texts[i] = string(randomText(rnd, alphabet, rnd.Intn(50)))
+	}
+	results, err := ac.MatchBatch(context.Background(), texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(texts) {
+		t.Fatalf("got %d results, want %d", len(results), len(texts))
+	}
+	for i, s := range texts {
+		var want []Hit[int]
+		ac.MustMatchStringFunc(s, func(h Hit[int]) bool {
+			want = append(want, h)
+			return true
+		})
+		if !reflect.DeepEqual(results[i], want) {
+			t.Fatalf("text %d %q:\n got %v\nwant %v", i, s, results[i], want)
+		}
+	}
+
+	if results, err := ac.MatchBatch(context.Background(), nil); err != nil || len(results) != 0 {
+		t.Errorf("empty input: got %v %v", results, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ac.MatchBatch(ctx, texts); err != context.Canceled {
+		t.Errorf("cancelled: got %v, want context.Canceled", err)
+	}
+	if _, err := GenAutomationOf[int]().MatchBatch(context.Background(), texts); err != ErrNotCompiled {
+		t.Errorf("not compiled: got %v, want ErrNotCompiled", err)
+	}
+}
+
+// tokenTexts 分词结果中每个词的内容，未登录词加上方括号
+func tokenTexts(seq []rune, tokens []Token[int]) []string {
+	var ss []string
+	for _, tk := range tokens {
+		s := string(seq[tk.Start:tk.End])
+		if tk.Index < 0 {
+			s = "[" + s + "]"
+		}
+		ss = append(ss, s)
+	}
+	return ss
+}
+
+func TestSegmenter(t *testing.T) {
+	ac := GenAutomationOf[int]()
+	for i, w := range []string{"研究", "研究生", "生命", "命", "起源", "语言", "go"} {
+		ac.MustInsert([]rune(w), i)
+	}
+	ac.MustCompile()
+	for _, c := range []struct {
+		mode SegmentMode
+		text string
+		want []string
+	}{
+		{SegmentForward, "研究生命起源", []string{"研究生", "命", "起源"}},
+		{SegmentBackward, "研究生命起源", []string{"研究", "生命", "起源"}},
+		{SegmentBidirectional, "研究生命起源", []string{"研究", "生命", "起源"}},
+		{SegmentForward, "研究生命起源吗？", []string{"研究生", "命", "起源", "[吗]", "[？]"}},
+		{SegmentForward, "用Go语言 2024写", []string{"[用]", "[Go]", "语言", "[ ]", "[2024]", "[写]"}},
+		{SegmentForward, "letsgo now", []string{"[lets]", "go", "[ ]", "[now]"}},
+		{SegmentBackward, "letsgo now", []string{"[lets]", "go", "[ ]", "[now]"}},
+		{SegmentForward, "", nil},
+	} {
+		seq := []rune(c.text)
+		sg := ac.MustNewSegmenter(c.mode)
+		if got := tokenTexts(seq, sg.Segment(seq)); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("mode %d %q: got %q, want %q", c.mode, c.text, got, c.want)
+		}
+	}
+
+	// 字节位置
+	s := "用Go语言2024写"
+	var got [][2]int
+	for _, tk := range ac.MustNewSegmenter(SegmentBidirectional).SegmentString(s) {
+		got = append(got, [2]int{tk.ByteStart, tk.ByteEnd})
+		if tk.Index >= 0 && string(ac.datas[tk.Index]) != s[tk.ByteStart:tk.ByteEnd] {
+			t.Errorf("token %v does not match %q", tk, s[tk.ByteStart:tk.ByteEnd])
+		}
+	}
+	if want := [][2]int{{0, 3}, {3, 5}, {5, 11}, {11, 15}, {15, 18}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("byte offsets: got %v, want %v", got, want)
+	}
+	if _, err := GenAutomationOf[int]().NewSegmenter(SegmentForward); err != ErrNotCompiled {
+		t.Errorf("got %v, want ErrNotCompiled", err)
+	}
+}
+
+func TestCount(t *testing.T) {
+	ac := buildWords(t, GenAutomationOf[int](), [][]rune{[]rune("aa"), []rune("b")})
+	if got := ac.MustCountString("aaaa b"); !reflect.DeepEqual(got, map[int]int{0: 3, 1: 1}) {
+		t.Errorf("MatchStandard: got %v", got)
+	}
+	ac.SetMatchKind(MatchLeftmostLongest)
+	if got := ac.MustCount([]rune("aaaa b")); !reflect.DeepEqual(got, map[int]int{0: 2, 1: 1}) {
+		t.Errorf("MatchLeftmostLongest: got %v", got)
+	}
+	if _, err := GenAutomationOf[int]().CountString("a"); err != ErrNotCompiled {
+		t.Errorf("got %v, want ErrNotCompiled", err)
+	}
+}
+
+func TestCounter(t *testing.T) {
+	ac := GenAutomationOf[string]()
+	for _, w := range [][2]string{{"ab", "x"}, {"cd", "y"}, {"ef", "x"}, {"gh", "z"}} {
+		ac.MustInsert([]rune(w[0]), w[1])
+	}
+	ac.MustCompile()
+	c := ac.MustNewCounter()
+	for _, doc := range []string{"ab cd ab", "cd ef", "ab", "ef"} {
+		c.AddString(doc)
+	}
+	if c.Docs() != 4 {
+		t.Errorf("Docs = %d, want 4", c.Docs())
+	}
+	type count struct{ index, count, docs int }
+	counts := func(top []KeywordCount[string]) []count {
+		var cs []count
+		for _, kc := range top {
+			cs = append(cs, count{kc.Index, kc.Count, kc.Docs})
+		}
+		return cs
+	}
+	// cd 和 ef 次数相同，按下标排列
+	if got, want := counts(c.TopK(0)), []count{{0, 3, 2}, {1, 2, 2}, {2, 2, 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TopK(0) = %v, want %v", got, want)
+	}
+	if got, want := counts(c.TopK(2)), []count{{0, 3, 2}, {1, 2, 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TopK(2) = %v, want %v", got, want)
+	}
+	if kc, err := c.Get(3); err != nil || kc.Count != 0 || string(kc.Data) != "gh" {
+		t.Errorf("Get(3) = %v %v", kc, err)
+	}
+	if _, err := c.Get(4); err != ErrBadIndex {
+		t.Errorf("Get(4): got %v, want ErrBadIndex", err)
+	}
+	if got := TotalsBy(c, func(v string) string { return v }); !reflect.DeepEqual(got, map[string]int{"x": 5, "y": 2}) {
+		t.Errorf("TotalsBy = %v", got)
+	}
+	c.Reset()
+	if c.Docs() != 0 || len(c.TopK(0)) != 0 {
+		t.Errorf("after Reset: %d docs, %v", c.Docs(), c.TopK(0))
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				c.AddString("ab cd ab")
+				c.Add([]rune("ef"))
+			}
+		}()
+	}
+	wg.Wait()
+	if got, want := counts(c.TopK(0)), []count{{0, 1600, 800}, {1, 800, 800}, {2, 800, 800}}; !reflect.DeepEqual(got, want) || c.Docs() != 1600 {
+		t.Errorf("concurrent: %d docs, TopK = %v, want %v", c.Docs(), got, want)
+	}
+}
+
+// bruteReplace 逐个位置取最长的 old 替换，同一个 old 取第一次出现的 new
+func bruteReplace(oldnew []string, s string) string {
+	var out strings.Builder
+	for i := 0; i < len(s); {
+		best, repl := 0, ""
+		for j := 0; j < len(oldnew); j += 2 {
+			if old := oldnew[j]; len(old) > best && strings.HasPrefix(s[i:], old) {
+				best, repl = len(old), oldnew[j+1]
+			}
+		}
+		if best == 0 {
+			out.WriteByte(s[i])
+			i++
+			continue
+		}
+		out.WriteString(repl)
+		i += best
+	}
+	return out.String()
+}
+
+// limitWriter 只实现 io.Writer，写满 n 个字节之后返回错误
+type limitWriter struct {
+	buf bytes.Buffer
+	n   int
+}
+
+var errLimit = fmt.Errorf("limitWriter: full")
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if w.buf.Len()+len(p) > w.n {
+		m := w.n - w.buf.Len()
+		w.buf.Write(p[:m])
+		return m, errLimit
+	}
+	return w.buf.Write(p)
+}
+
+func TestReplacer(t *testing.T) {
+	oldnew := []string{"a", "1", "ab", "2", "abc", "3", "a", "9"}
+	if got := NewReplacer(oldnew...).Replace("xabcabaz"); got != "x321z" {
+		t.Errorf("got %q, want %q", got, "x321z")
+	}
+
+	rnd := rand.New(rand.NewSource(7))
+	for round := 0; round < 200; round++ {
+		words := randomWords(rnd, []rune("abc"), 1+rnd.Intn(8), 4)
+		oldnew := make([]string, 0, 2*len(words))
+		for i, w := range words {
+			oldnew = append(oldnew, string(w), fmt.Sprintf("<%d>", i))
+		}
+		r := NewReplacer(oldnew...)
+		s := string(randomText(rnd, []rune("abcd"), rnd.Intn(40)))
+		want := bruteReplace(oldnew, s)
+		if got := r.Replace(s); got != want {
+			t.Fatalf("round %d: %q on %q: got %q, want %q", round, oldnew, s, got, want)
+		}
+		// 只实现了 Write 的 writer
+		w := &limitWriter{n: len(want)}
+		if n, err := r.WriteString(w, s); err != nil || n != len(want) || w.buf.String() != want {
+			t.Fatalf("round %d: WriteString = %d %v %q, want %q", round, n, err, w.buf.String(), want)
+		}
+	}
+
+	r := NewReplacer("ab", "XYZ")
+	for limit := 0; limit < len("cXYZXYZ"); limit++ {
+		w := &limitWriter{n: limit}
+		n, err := r.WriteString(w, "cabab")
+		if err != errLimit || n != limit || w.buf.String() != "cXYZXYZ"[:limit] {
+			t.Errorf("limit %d: got %d %v %q", limit, n, err, w.buf.String())
+		}
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	datas := func(entries []Entry[int]) []string {
+		var ds []string
+		for _, e := range entries {
+			ds = append(ds, string(e.Data))
+		}
+		return ds
+	}
+	for _, be := range []Backend{BackendMap, BackendDoubleArray} {
+		// 插入顺序与字典序不同，结果只按字典序排列
+		ac := buildWords(t, GenAutomationOf[int]().SetBackend(be),
+			[][]rune{[]rune("b"), []rune("ac"), []rune("abd"), []rune("ab"), []rune("abc")})
+		for _, c := range []struct {
+			prefix string
+			limit  int
+			want   []string
+		}{
+			{"", 0, []string{"ab", "abc", "abd", "ac", "b"}},
+			{"a", 0, []string{"ab", "abc", "abd", "ac"}},
+			{"a", 2, []string{"ab", "abc"}},
+			{"ab", -1, []string{"ab", "abc", "abd"}},
+			{"abc", 0, []string{"abc"}},
+			{"abe", 0, nil},
+			{"c", 0, nil},
+		} {
+			if got := datas(ac.MustPrefixSearch([]rune(c.prefix), c.limit)); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("%v: PrefixSearch(%q, %d) = %q, want %q", be, c.prefix, c.limit, got, c.want)
+			}
+			if got := ac.MustHasPrefix([]rune(c.prefix)); got != (c.want != nil) {
+				t.Errorf("%v: HasPrefix(%q) = %v", be, c.prefix, got)
+			}
+		}
+		for _, c := range []struct {
+			seq   string
+			want  string
+			found bool
+		}{
+			{"abcd", "abc", true},
+			{"abx", "ab", true},
+			{"a", "", false},
+			{"xab", "", false},
+		} {
+			h, ok := ac.MustLongestPrefix([]rune(c.seq))
+			if ok != c.found || ok && (string(ac.datas[h.Index]) != c.want || h.Start != 0 || h.End != len(c.want)) {
+				t.Errorf("%v: LongestPrefix(%q) = %+v %v, want %q", be, c.seq, h, ok, c.want)
+			}
+		}
+	}
+	empty := buildWords(t, GenAutomationOf[int](), nil)
+	if empty.MustHasPrefix(nil) || empty.MustPrefixSearch(nil, 0) != nil {
+		t.Error("empty automation has prefix \"\"")
+	}
+
+	// 三个方法都按形近字符表展开，'1' 可以代替 'l' 和 'i'
+	ac := GenAutomationOf[int]().SetConfusables(Confusables{}.Add('1', 'l', 'i'))
+	buildWords(t, ac, [][]rune{[]rune("lab"), []rune("ice"), []rune("1x")})
+	if got, want := datas(ac.MustPrefixSearch([]rune("1"), 0)), []string{"1x", "ice", "lab"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PrefixSearch(\"1\") = %q, want %q", got, want)
+	}
+	if !ac.MustHasPrefix([]rune("1c")) || ac.MustHasPrefix([]rune("1y")) {
+		t.Error("HasPrefix ignores confusables")
+	}
+	if h, ok := ac.MustLongestPrefix([]rune("1ce!")); !ok || h.Index != 1 {
+		t.Errorf("LongestPrefix(\"1ce!\") = %+v %v", h, ok)
+	}
+}
+
+func TestDebugOutput(t *testing.T) {
+	words := []string{"he", "she", "his", "hers"}
+	ac := GenAutomationOf[int]()
+	for i, w := range words {
+		ac.MustInsert([]rune(w), i)
+	}
+	ac.MustCompile()
+
+	st := ac.MustStats()
+	// 根、h、he、her、hers、hi、his、s、sh、she，有子状态的 7 个状态共 9 条边
+	if st.Nodes != 10 || st.Patterns != 4 || st.MaxDepth != 4 || st.AvgFanout != 9.0/7 || st.Memory <= 0 {
+		t.Errorf("Stats = %+v", st)
+	}
+
+	var buf bytes.Buffer
+	ac.MustDumpJSON(&buf)
+	var dump dumpAutomation
+	if err := json.Unmarshal(buf.Bytes(), &dump); err != nil {
+		t.Fatal(err)
+	}
+	if dump.Stats != st || len(dump.Nodes) != st.Nodes {
+		t.Fatalf("dump stats %+v with %d nodes", dump.Stats, len(dump.Nodes))
+	}
+	// 沿 edges 找出每个前缀对应的状态
+	ids := map[string]int{"": 0}
+	paths := map[int]string{0: ""}
+	for _, n := range dump.Nodes {
+		for _, e := range n.Edges {
+			ids[paths[n.ID]+e.Label] = e.To
+			paths[e.To] = paths[n.ID] + e.Label
+		}
+	}
+	if len(ids) != st.Nodes {
+		t.Fatalf("reachable prefixes %v", ids)
+	}
+	for path, id := range ids {
+		n := dump.Nodes[id]
+		if n.ID != id || n.Depth != len(path) {
+			t.Errorf("%q: id %d depth %d", path, n.ID, n.Depth)
+		}
+	}
+	for path, want := range map[string]string{"sh": "h", "she": "he", "his": "s", "hers": "s", "he": "", "h": ""} {
+		if got := paths[dump.Nodes[ids[path]].Fail]; got != want {
+			t.Errorf("fail(%q) = %q, want %q", path, got, want)
+		}
+	}
+	if got := paths[dump.Nodes[ids["she"]].Output]; got != "he" {
+		t.Errorf("output(she) = %q, want he", got)
+	}
+	terminals := map[string]int{}
+	for _, n := range dump.Nodes {
+		if n.Index >= 0 {
+			if n.Pattern != paths[n.ID] {
+				t.Errorf("state %q has pattern %q", paths[n.ID], n.Pattern)
+			}
+			terminals[n.Pattern] = n.Index
+		}
+	}
+	if want := map[string]int{"he": 0, "she": 1, "his": 2, "hers": 3}; !reflect.DeepEqual(terminals, want) {
+		t.Errorf("terminals %v, want %v", terminals, want)
+	}
+
+	buf.Reset()
+	ac.MustWriteDOT(&buf)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if lines[0] != "digraph automation {" || lines[len(lines)-1] != "}" {
+		t.Fatalf("DOT not wrapped in digraph: %q", buf.String())
+	}
+	gotos, fails, doubles := 0, 0, 0
+	for _, line := range lines[1 : len(lines)-1] {
+		if !strings.HasPrefix(line, "\t") || !strings.HasSuffix(line, ";") || strings.Count(line, `"`)%2 != 0 {
+			t.Errorf("bad DOT statement %q", line)
+		}
+		switch {
+		case strings.Contains(line, "style=dashed"):
+			fails++
+		case strings.Contains(line, "->"):
+			gotos++
+		case strings.Contains(line, "doublecircle"):
+			doubles++
+		}
+	}
+	if gotos != st.Nodes-1 || fails != 4 || doubles != 4 {
+		t.Errorf("DOT has %d goto edges, %d fail edges, %d terminals", gotos, fails, doubles)
+	}
+	for _, want := range []string{
+		fmt.Sprintf("\t%d -> %d [label=\"e\"];", ids["sh"], ids["she"]),
+		fmt.Sprintf("\t%d -> %d [style=dashed, color=gray];", ids["she"], ids["he"]),
+		fmt.Sprintf("\t%d [shape=doublecircle, label=\"%d\\n#3 hers\"];", ids["hers"], ids["hers"]),
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("DOT missing %q", want)
+		}
+	}
+}
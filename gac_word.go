@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"unicode"
+)
+
+// 模式串两端的单词边界要求
+const (
+	boundStart uint8 = 1 << iota // 命中之前的字符不能是单词字符
+	boundEnd                     // 命中之后的字符不能是单词字符
+)
+
+// SetWordBoundary 设置之后 Insert 的模式串是否按整词匹配
+// 整词匹配时，以单词字符开头（结尾）的模式串，命中之前（之后）的原始字符不能是单词字符，
+// 例如 "ass" 不会命中 "class"；单词字符指字母，但不包括汉字、假名、谚文，
+// 因此中日韩模式串仍然按子串匹配，"QQ群" 只要求开头的边界，"我是ass" 中的 "ass" 仍然命中
+func (ac *AutomationOf[V]) SetWordBoundary(word bool) *AutomationOf[V] {
+	ac.word = word
+	return ac
+}
+
+// InsertWord 插入按整词匹配的模式串，不受 SetWordBoundary 影响，已经 Compile 时返回 ErrCompiled
+func (ac *AutomationOf[V]) InsertWord(data []rune, value V) error {
+	_, err := ac.insert(data, value, true)
+	return err
+}
+
+// MustInsertWord 同 InsertWord，出错时 panic
+func (ac *AutomationOf[V]) MustInsertWord(data []rune, value V) {
+	must(ac.InsertWord(data, value))
+}
+
+// wordBounds 归一化之后的模式串 key 两端的单词边界要求
+func wordBounds(key []rune, word bool) uint8 {
+	var bounds uint8
+	if word && isWordRune(key[0]) {
+		bounds |= boundStart
+	}
+	if word && isWordRune(key[len(key)-1]) {
+		bounds |= boundEnd
+	}
+	return bounds
+}
+
+// isWordRune 是否为单词字符，中日韩文字不以空格分词，不算单词字符
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) && !unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}